@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"ezbook-convert/internal/anonymizer"
+	"ezbook-convert/internal/config"
+)
+
+// ApplyResponseCmd merges LLM responses into the config (update-config --apply)
+// Placeholders from the mapping file are translated back to the real names before merging
+// Several responses (one per prompt part) are combined first and treated as partial, so nothing is removed
+func ApplyResponseCmd(responsePaths []string, configPath, mappingPath string, allowRemovals bool) error {
+	if len(responsePaths) > 1 && allowRemovals {
+		return fmt.Errorf("--allow-removals needs a single complete response, not %d partial ones", len(responsePaths))
+	}
+
+	cfg, err := loadConfigOrDefault(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	mapping, err := anonymizer.LoadMapping(mappingPath)
+	if err != nil {
+		return fmt.Errorf("failed to load placeholder mapping: %w", err)
+	}
+
+	var responses []*config.Config
+	for _, responsePath := range responsePaths {
+		data, err := os.ReadFile(responsePath)
+		if err != nil {
+			return fmt.Errorf("failed to read LLM response: %w", err)
+		}
+		response, err := parseResponse(responsePath, data, cfg, mapping, mappingPath)
+		if err != nil {
+			return err
+		}
+		responses = append(responses, response)
+	}
+
+	return applyResponses(cfg, configPath, responses, allowRemovals)
+}
+
+// applyResponses merges parsed responses into the config, prints the changes and saves it
+func applyResponses(cfg *config.Config, configPath string, responses []*config.Config, allowRemovals bool) error {
+	incoming := responses[0]
+	for _, response := range responses[1:] {
+		incoming, _ = config.Merge(incoming, response, false)
+	}
+
+	merged, changes := config.Merge(cfg, incoming, allowRemovals)
+
+	applied, kept := 0, 0
+	for _, change := range changes {
+		if change.Op == "keep" {
+			kept++
+		} else {
+			applied++
+		}
+	}
+
+	if applied == 0 {
+		fmt.Println("✓ The response contains no new rules.")
+		return nil
+	}
+
+	fmt.Println("Changes:")
+	for _, change := range changes {
+		if change.Op != "keep" {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+
+	if kept > 0 && len(responses) > 1 {
+		fmt.Printf("\n%d existing item(s) not mentioned in the partial responses were kept\n", kept)
+	} else if kept > 0 {
+		fmt.Printf("\n%d item(s) missing from the response were kept (use --allow-removals to delete them):\n", kept)
+		for _, change := range changes {
+			if change.Op == "keep" {
+				fmt.Printf("  %s\n", change)
+			}
+		}
+	}
+
+	if err := saveConfig(configPath, merged); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("\n✓ %d change(s) written to: %s\n", applied, configPath)
+
+	return nil
+}
+
+// parseResponse parses one LLM response and translates its placeholders
+// name identifies the response in messages (file path or prompt part)
+func parseResponse(name string, data []byte, base *config.Config, mapping *anonymizer.Mapping, mappingPath string) (*config.Config, error) {
+	// The response is usually a code block surrounded by prose, so parse it tolerantly
+	incoming, fixes, err := config.Repair(data)
+	if len(fixes) > 0 {
+		fmt.Printf("Applied %d fix(es) to %s:\n", len(fixes), name)
+		for _, fix := range fixes {
+			fmt.Printf("  • %s\n", fix)
+		}
+		fmt.Println()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse LLM response %s: %w", name, err)
+	}
+
+	if err := validateResponse(incoming, base); err != nil {
+		return nil, fmt.Errorf("invalid LLM response %s: %w", name, err)
+	}
+
+	if translated := translatePlaceholders(incoming, mapping); translated > 0 {
+		fmt.Printf("Translated %d placeholder(s) in %s back to real names using %s\n\n", translated, name, mappingPath)
+	}
+
+	// A placeholder left in a new rule would never match, and could replace the real name with --allow-removals
+	if unresolved := unresolvedPlaceholders(incoming, base); len(unresolved) > 0 {
+		return nil, fmt.Errorf("LLM response %s contains placeholders that are not in %s: %s",
+			name, mappingPath, strings.Join(unresolved, ", "))
+	}
+
+	return incoming, nil
+}
+
+// unresolvedPlaceholders lists the personal data placeholders left in the response after translation
+// Values the config already contains are ignored, older configs may hold generic placeholders
+func unresolvedPlaceholders(incoming, base *config.Config) []string {
+	existing := make(map[string]bool)
+	addExisting := func(values []string) {
+		for _, value := range values {
+			existing[value] = true
+		}
+	}
+	addExisting(base.KnownPartners)
+	for _, category := range base.Categories {
+		if category != nil {
+			addExisting(category.Keywords)
+			addExisting(category.ExactMatches)
+		}
+	}
+
+	var unresolved []string
+	seen := make(map[string]bool)
+	check := func(values []string) {
+		for _, value := range values {
+			if existing[value] {
+				continue
+			}
+			for _, placeholder := range anonymizer.FindPlaceholders(value) {
+				if !seen[placeholder] {
+					seen[placeholder] = true
+					unresolved = append(unresolved, placeholder)
+				}
+			}
+		}
+	}
+	check(incoming.KnownPartners)
+	for _, category := range incoming.Categories {
+		if category != nil {
+			check(category.Keywords)
+			check(category.ExactMatches)
+		}
+	}
+
+	sort.Strings(unresolved)
+	return unresolved
+}
+
+// translatePlaceholders replaces numbered placeholders with the original values
+// Placeholders listed as keywords are moved to exact_matches, as a real name is only useful as exact match
+func translatePlaceholders(cfg *config.Config, mapping *anonymizer.Mapping) int {
+	translated := 0
+
+	resolve := func(values []string) []string {
+		var result []string
+		for _, value := range values {
+			value, count := mapping.ResolveText(value)
+			translated += count
+			result = append(result, value)
+		}
+		return result
+	}
+
+	cfg.KnownPartners = resolve(cfg.KnownPartners)
+
+	for _, category := range cfg.Categories {
+		if category == nil {
+			continue
+		}
+		category.ExactMatches = resolve(category.ExactMatches)
+
+		var keywords []string
+		for _, keyword := range category.Keywords {
+			if original, ok := mapping.Resolve(keyword); ok {
+				category.ExactMatches = append(category.ExactMatches, original)
+				translated++
+				continue
+			}
+			keywords = append(keywords, keyword)
+		}
+		category.Keywords = keywords
+	}
+
+	return translated
+}
+
+// validateResponse checks that the response looks like a categorization config
+// Partial responses may omit the subcategory of categories that already exist in base;
+// new categories without rules are dropped
+func validateResponse(cfg, base *config.Config) error {
+	if len(cfg.Categories) == 0 && len(cfg.KnownPartners) == 0 {
+		return fmt.Errorf("no known_partners or categories found")
+	}
+
+	for name, category := range cfg.Categories {
+		if category == nil {
+			return fmt.Errorf("category %q has no rules", name)
+		}
+		// A new category needs a rule, otherwise the saved config would fail to load
+		if _, ok := base.Categories[name]; !ok && len(category.Keywords) == 0 && len(category.ExactMatches) == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: category %q has no keywords or exact_matches, skipped\n", name)
+			delete(cfg.Categories, name)
+			continue
+		}
+		if existing, ok := base.Categories[name]; category.SubCategory == "" && (!ok || existing == nil || existing.SubCategory == "") {
+			fmt.Fprintf(os.Stderr, "Warning: category %q has no subcategory\n", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ezbook-convert/internal/anonymizer"
+	"ezbook-convert/internal/config"
+)
+
+func TestApplyPartialResponses(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	mappingPath := filepath.Join(dir, "mapping.yaml")
+
+	cfg := config.New()
+	cfg.KnownPartners = []string{"MOL"}
+	cfg.Categories["Transportation"] = &config.Category{SubCategory: "Fuel", Keywords: []string{"mol"}}
+	if _, err := config.SaveConfig(configPath, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	mapping := anonymizer.NewMapping()
+	mapping.Placeholder("PERSON", "Nagy Anna")
+	if err := anonymizer.SaveMapping(mappingPath, mapping); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := []string{
+		"known_partners:\n  - TESCO\ncategories:\n  Food & Drink:\n    subcategory: Groceries\n    keywords:\n      - tesco\n",
+		"Here you go:\n```yaml\nknown_partners:\n  - [PERSON_1]\ncategories:\n  Housing & Houseware:\n    subcategory: Rent\n    exact_matches:\n      - [PERSON_1]\n```\n",
+	}
+	var paths []string
+	for i, content := range responses {
+		path := filepath.Join(dir, fmt.Sprintf("part-%d.yaml", i+1))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	if err := ApplyResponseCmd(paths, configPath, mappingPath, true); err == nil {
+		t.Error("--allow-removals with several responses should fail")
+	}
+	if err := ApplyResponseCmd(paths, configPath, mappingPath, false); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"MOL", "TESCO", "Nagy Anna"}; !reflect.DeepEqual(saved.KnownPartners, want) {
+		t.Errorf("known_partners = %q, want %q", saved.KnownPartners, want)
+	}
+	for _, name := range []string{"Transportation", "Food & Drink", "Housing & Houseware"} {
+		if saved.Categories[name] == nil {
+			t.Errorf("category %s missing after apply", name)
+		}
+	}
+	if got := saved.Categories["Housing & Houseware"].ExactMatches; !reflect.DeepEqual(got, []string{"Nagy Anna"}) {
+		t.Errorf("exact matches = %q, want the real name", got)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	base := config.New()
+	if err := validateResponse(&config.Config{}, base); err == nil {
+		t.Error("empty response should be rejected")
+	}
+	if err := validateResponse(&config.Config{Categories: map[string]*config.Category{"Empty": nil}}, base); err == nil {
+		t.Error("null category should be rejected")
+	}
+
+	base.Categories["Food & Drink"] = &config.Category{SubCategory: "Groceries", Keywords: []string{"tesco"}}
+	response := &config.Config{Categories: map[string]*config.Category{
+		"Food & Drink":  {},
+		"Entertainment": {SubCategory: "Streaming"},
+		"Shopping":      {SubCategory: "Online", Keywords: []string{"amazon"}},
+	}}
+	if err := validateResponse(response, base); err != nil {
+		t.Fatal(err)
+	}
+	// Existing categories keep their rules through the merge, new ones need their own
+	if _, ok := response.Categories["Entertainment"]; ok {
+		t.Error("new category without rules should be dropped")
+	}
+	if _, ok := response.Categories["Food & Drink"]; !ok {
+		t.Error("existing category without rules in the response should be kept")
+	}
+	if _, ok := response.Categories["Shopping"]; !ok {
+		t.Error("new category with keywords should be kept")
+	}
+}
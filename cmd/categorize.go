@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/parser"
+	"golang.org/x/term"
+)
+
+// Number of sample transactions shown per partner
+const categorizeSamples = 3
+
+// CategorizeCmd executes the categorize command
+// It walks through the unknown partners and saves the config after every decision,
+// so the session can be stopped at any time and resumed later
+func CategorizeCmd(inputPath, configPath string) error {
+	cfg, err := loadConfigOrDefault(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	inputFile, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer inputFile.Close()
+
+	khTransactions, rowErrors, err := parser.ParseKHExport(inputFile, inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse K&H export: %w", err)
+	}
+	reportRowErrors(rowErrors)
+
+	var partnerNames []string
+	for _, t := range khTransactions {
+		if t.PartnerName != "" {
+			partnerNames = append(partnerNames, t.PartnerName)
+		}
+	}
+
+	// The model doesn't change during the session, only the rules do
+	model, err := loadModel(cfg, configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load classifier model: %w", err)
+	}
+	newCategorizer := func() *categorizer.Categorizer {
+		cat := categorizer.New(cfg)
+		if model != nil {
+			cat.SetClassifier(model, cfg.Classifier.MinConfidence)
+		}
+		return cat
+	}
+
+	cat := newCategorizer()
+	uncategorized := cat.GetUncategorizedPartners(partnerNames)
+	if len(uncategorized) == 0 {
+		fmt.Println("✓ All merchants are already in the known_partners list!")
+		return nil
+	}
+
+	// Sample transactions and stats per canonical partner name
+	samples := make(map[string][]*parser.KHTransaction)
+	for _, t := range khTransactions {
+		name := cat.Canonicalize(t.PartnerName)
+		samples[name] = append(samples[name], t)
+	}
+	stats := collectMerchantStats(khTransactions, cat)
+
+	session := &categorizeSession{
+		in:         bufio.NewReader(os.Stdin),
+		keys:       isTerminal(os.Stdin),
+		cfg:        cfg,
+		configPath: configPath,
+	}
+
+	fmt.Printf("%d unknown partner(s). Changes are saved to %s after every step.\n", len(uncategorized), configPath)
+
+	done := 0
+	for i, partner := range uncategorized {
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(uncategorized), partner)
+		fmt.Printf("  %s\n", stats[partner].Summary())
+		for j, t := range samples[partner] {
+			if j == categorizeSamples {
+				fmt.Printf("  ... and %d more\n", len(samples[partner])-categorizeSamples)
+				break
+			}
+			fmt.Printf("  %s  %s %s  %s", t.Date, t.Amount, t.Currency, t.Type)
+			if t.Description != "" {
+				fmt.Printf("  \"%s\"", t.Description)
+			}
+			fmt.Println()
+		}
+
+		// Keyword and fuzzy rules can match partners added earlier in this session
+		cat = newCategorizer()
+		first := samples[partner][0]
+		suggestions := suggestCategories(cat.Explain(first.PartnerName, first.Type, first.Description, true))
+
+		quit, err := session.ask(partner, suggestions)
+		if err != nil {
+			return err
+		}
+		if quit {
+			break
+		}
+		done++
+	}
+
+	fmt.Printf("\n✓ %d of %d partner(s) reviewed, config saved to: %s\n", done, len(uncategorized), configPath)
+	return nil
+}
+
+// suggestCategories keeps the first match per category, skipping the default fallback
+func suggestCategories(matches []categorizer.Match) []categorizer.Match {
+	var suggestions []categorizer.Match
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if m.Tier == "default" || seen[m.Category] {
+			continue
+		}
+		seen[m.Category] = true
+		suggestions = append(suggestions, m)
+	}
+	return suggestions
+}
+
+// categorizeSession reads the user's choices and applies them to the config
+type categorizeSession struct {
+	in         *bufio.Reader
+	keys       bool // Choices are single keystrokes, stdin is a terminal
+	cfg        *config.Config
+	configPath string
+}
+
+// ask handles one partner, returning true if the user wants to quit
+func (s *categorizeSession) ask(partner string, suggestions []categorizer.Match) (bool, error) {
+	for i, m := range suggestions {
+		fmt.Printf("  [%d] %s / %s  (%s)\n", i+1, m.Category, m.SubCategory, describeMatch(m))
+	}
+	fmt.Println("  [c] choose category  [k] add keyword  [i] ignore  [s] skip  [q] quit")
+
+	// Suggestions beyond 9 need more than one key
+	keys := s.keys && len(suggestions) <= 9
+
+	for {
+		answer, err := s.choice("> ", keys)
+		if err != nil {
+			return true, nil
+		}
+
+		switch answer {
+		case "c":
+			category, ok, err := s.chooseCategory()
+			if err != nil || !ok {
+				continue
+			}
+			return false, s.addExactMatch(partner, category)
+		case "k":
+			keyword, err := s.prompt(fmt.Sprintf("  Keyword (Enter for \"%s\"): ", strings.ToLower(partner)))
+			if err != nil {
+				continue
+			}
+			if keyword == "" {
+				keyword = strings.ToLower(partner)
+			}
+			category, ok, err := s.chooseCategory()
+			if err != nil || !ok {
+				continue
+			}
+			return false, s.addKeyword(partner, keyword, category)
+		case "i":
+			s.cfg.AddKnownPartner(partner)
+			return false, s.save(fmt.Sprintf("%s ignored (added to known_partners only)", partner))
+		case "s", "":
+			return false, nil
+		case "q":
+			return true, nil
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(suggestions) {
+			// Classifier and type fallback suggestions may name a category missing from the config
+			suggestion := suggestions[n-1]
+			if _, exists := s.cfg.Categories[suggestion.Category]; !exists {
+				s.cfg.Categories[suggestion.Category] = &config.Category{SubCategory: suggestion.SubCategory}
+			}
+			return false, s.addExactMatch(partner, suggestion.Category)
+		}
+		fmt.Println("  Unknown choice")
+	}
+}
+
+// chooseCategory lists the configured categories or creates a new one
+func (s *categorizeSession) chooseCategory() (string, bool, error) {
+	var names []string
+	for name := range s.cfg.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		subCategory := ""
+		if category := s.cfg.Categories[name]; category != nil {
+			subCategory = category.SubCategory
+		}
+		fmt.Printf("    [%d] %s / %s\n", i+1, name, subCategory)
+	}
+	fmt.Println("    [n] new category  [Enter] back")
+
+	answer, err := s.prompt("  Category: ")
+	if err != nil || answer == "" {
+		return "", false, err
+	}
+
+	if answer == "n" {
+		name, err := s.prompt(fmt.Sprintf("  Category name (e.g. %s): ", config.DefaultExpenseCategories[0]))
+		if err != nil || name == "" {
+			return "", false, err
+		}
+		subCategory, err := s.prompt("  Subcategory: ")
+		if err != nil {
+			return "", false, err
+		}
+		if _, exists := s.cfg.Categories[name]; !exists {
+			s.cfg.Categories[name] = &config.Category{SubCategory: subCategory}
+		}
+		return name, true, nil
+	}
+
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(names) {
+		fmt.Println("  Unknown category")
+		return "", false, nil
+	}
+	return names[n-1], true, nil
+}
+
+func (s *categorizeSession) addExactMatch(partner, categoryName string) error {
+	category := s.category(categoryName)
+	category.ExactMatches = append(category.ExactMatches, partner)
+	s.cfg.AddKnownPartner(partner)
+	return s.save(fmt.Sprintf("%s → %s (exact match)", partner, categoryName))
+}
+
+func (s *categorizeSession) addKeyword(partner, keyword, categoryName string) error {
+	category := s.category(categoryName)
+	for _, existing := range category.Keywords {
+		if strings.EqualFold(existing, keyword) {
+			s.cfg.AddKnownPartner(partner)
+			return s.save(fmt.Sprintf("%s → %s (keyword \"%s\" already present)", partner, categoryName, keyword))
+		}
+	}
+	category.Keywords = append(category.Keywords, keyword)
+	s.cfg.AddKnownPartner(partner)
+	return s.save(fmt.Sprintf("%s → %s (keyword \"%s\")", partner, categoryName, keyword))
+}
+
+func (s *categorizeSession) category(name string) *config.Category {
+	category := s.cfg.Categories[name]
+	if category == nil {
+		category = &config.Category{}
+		s.cfg.Categories[name] = category
+	}
+	return category
+}
+
+func (s *categorizeSession) save(message string) error {
+	if err := saveConfig(s.configPath, s.cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Printf("  ✓ %s\n", message)
+	return nil
+}
+
+// choice reads a single keystroke without waiting for Enter if keys is set, otherwise a line
+// The terminal is in raw mode only while waiting for the key, so Ctrl-C arrives as a key and quits
+func (s *categorizeSession) choice(label string, keys bool) (string, error) {
+	if !keys {
+		return s.prompt(label)
+	}
+
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return s.prompt(label)
+	}
+	fmt.Print(label)
+	key, _, err := s.in.ReadRune()
+	term.Restore(fd, state)
+
+	switch {
+	case err != nil, key == 3, key == 4: // Ctrl-C, Ctrl-D
+		fmt.Println()
+		return "", io.EOF
+	case key == '\r', key == '\n':
+		fmt.Println()
+		return "", nil
+	}
+	fmt.Println(string(key))
+	return string(key), nil
+}
+
+// prompt reads one trimmed line, returning io.EOF when input ends
+func (s *categorizeSession) prompt(label string) (string, error) {
+	fmt.Print(label)
+	line, err := s.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		fmt.Println()
+		return "", io.EOF
+	}
+	return strings.TrimSpace(line), nil
+}
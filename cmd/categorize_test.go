@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bufio"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/config"
+)
+
+func newTestSession(t *testing.T, input string) *categorizeSession {
+	t.Helper()
+	cfg := config.New()
+	cfg.Categories["Food & Drink"] = &config.Category{SubCategory: "Groceries", Keywords: []string{"spar"}}
+	cfg.Categories["Transportation"] = &config.Category{SubCategory: "Fuel", Keywords: []string{"mol"}}
+	return &categorizeSession{
+		in:         bufio.NewReader(strings.NewReader(input)),
+		cfg:        cfg,
+		configPath: filepath.Join(t.TempDir(), "categories.yaml"),
+	}
+}
+
+func TestCategorizeSession(t *testing.T) {
+	suggestions := []categorizer.Match{{Category: "Finance & Insurance", SubCategory: "Service Charge", Tier: "type"}}
+
+	tests := []struct {
+		name     string
+		input    string
+		quit     bool
+		category string
+		keywords []string
+		exact    []string
+	}{
+		{"accept suggestion", "1\n", false, "Finance & Insurance", nil, []string{"TESCO ÁRUHÁZ"}},
+		{"choose category", "x\nc\n1\n", false, "Food & Drink", []string{"spar"}, []string{"TESCO ÁRUHÁZ"}},
+		{"keyword with default", "k\n\n1\n", false, "Food & Drink", []string{"spar", "tesco áruház"}, nil},
+		{"keyword", "k\ntesco\n2\n", false, "Transportation", []string{"mol", "tesco"}, nil},
+		{"new category", "c\nn\nShopping\nOther\n", false, "Shopping", nil, []string{"TESCO ÁRUHÁZ"}},
+		{"back from category list", "c\n\ns\n", false, "", nil, nil},
+		{"quit", "q\n", true, "", nil, nil},
+		{"end of input", "", true, "", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSession(t, tt.input)
+			quit, err := s.ask("TESCO ÁRUHÁZ", suggestions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quit != tt.quit {
+				t.Errorf("quit = %v, want %v", quit, tt.quit)
+			}
+			if tt.category == "" {
+				if s.cfg.IsKnownPartner("TESCO ÁRUHÁZ") {
+					t.Error("partner was added to known_partners")
+				}
+				return
+			}
+
+			saved, err := config.LoadConfig(s.configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			category := saved.Categories[tt.category]
+			if category == nil {
+				t.Fatalf("category %s missing from the saved config", tt.category)
+			}
+			if !reflect.DeepEqual(category.Keywords, tt.keywords) || !reflect.DeepEqual(category.ExactMatches, tt.exact) {
+				t.Errorf("%s = keywords %q, exact matches %q, want %q, %q", tt.category, category.Keywords, category.ExactMatches, tt.keywords, tt.exact)
+			}
+			if !saved.IsKnownPartner("TESCO ÁRUHÁZ") {
+				t.Error("partner missing from known_partners")
+			}
+		})
+	}
+}
+
+func TestSuggestCategories(t *testing.T) {
+	matches := []categorizer.Match{
+		{Category: "Food & Drink", Tier: "keyword", Rule: "tesco"},
+		{Category: "Food & Drink", Tier: "fuzzy", Rule: "TESCO EXPRESS"},
+		{Category: "Shopping", Tier: "model"},
+		{Category: "Miscellaneous", Tier: "default"},
+	}
+	var got []string
+	for _, m := range suggestCategories(matches) {
+		got = append(got, m.Category+" "+m.Tier)
+	}
+	if want := []string{"Food & Drink keyword", "Shopping model"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("suggestCategories = %q, want %q", got, want)
+	}
+}
@@ -1,17 +1,33 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/classifier"
 	"ezbook-convert/internal/config"
 	"ezbook-convert/internal/converter"
 	"ezbook-convert/internal/parser"
 )
 
+// ConvertOptions holds the convert command's flags
+type ConvertOptions struct {
+	InputPath   string
+	OutputPath  string
+	AccountName string
+	ConfigPath  string
+	Strict      bool   // Fail on the first row that can't be read or converted
+	RejectsPath string // Write skipped rows to this TSV file
+}
+
 // ConvertCmd executes the convert command
-func ConvertCmd(inputPath, outputPath, accountName, configPath string) error {
+func ConvertCmd(opts ConvertOptions) error {
+	inputPath, outputPath, configPath := opts.InputPath, opts.OutputPath, opts.ConfigPath
+
 	// Load config
 	cfg, err := loadConfigOrDefault(configPath)
 	if err != nil {
@@ -25,37 +41,66 @@ func ConvertCmd(inputPath, outputPath, accountName, configPath string) error {
 	}
 	defer inputFile.Close()
 
-	khTransactions, err := parser.ParseKHExport(inputFile)
+	cat := categorizer.New(cfg)
+	if err := loadClassifier(cat, cfg, configPath); err != nil {
+		return fmt.Errorf("failed to load classifier model: %w", err)
+	}
+	conv := converter.New(cat, opts.AccountName)
+	conv.Strict = opts.Strict
+
+	// Parse, categorize and write row by row
+	outputFile, err := os.Create(outputPath)
 	if err != nil {
-		return fmt.Errorf("failed to parse K&H export: %w", err)
+		return fmt.Errorf("failed to create output file: %w", err)
 	}
+	defer outputFile.Close()
 
-	fmt.Printf("Parsed %d transactions from K&H export\n", len(khTransactions))
+	writer, err := converter.NewCSVWriter(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
 
-	// Convert to ezBookkeeping format
-	cat := categorizer.New(cfg)
-	conv := converter.New(cat, accountName)
+	khReader := parser.NewKHReader(inputFile, inputPath)
+	inferred := &inferredMatches{seen: make(map[string]bool)}
+	conv.Matched = inferred.note
+	result, err := conv.Stream(khReader, writer)
+	if err != nil {
+		// Don't leave a partial file behind that could be imported by mistake
+		outputFile.Close()
+		os.Remove(outputPath)
+		if opts.Strict && errors.As(err, new(*parser.RowError)) {
+			return fmt.Errorf("%w (--strict stops at the first bad row)", err)
+		}
+		return fmt.Errorf("failed to convert K&H export: %w", err)
+	}
 
-	ezTransactions, convErrors := conv.Convert(khTransactions)
+	// Rejected rows are counted separately, they are listed below
+	fmt.Printf("Parsed %d transactions from K&H export", result.Read-len(result.Errors))
+	if len(result.Errors) > 0 {
+		fmt.Printf(", %d row(s) rejected", len(result.Errors))
+	}
+	fmt.Println()
 
-	// Report conversion errors
-	if len(convErrors) > 0 {
-		fmt.Fprintf(os.Stderr, "\nWarning: %d transactions failed to convert:\n", len(convErrors))
-		for _, err := range convErrors {
-			fmt.Fprintf(os.Stderr, "  - %v\n", err)
+	// Report rows that couldn't be read or converted
+	if len(result.Errors) > 0 {
+		fmt.Fprintln(os.Stderr)
+		reportRowErrors(result.Errors)
+	}
+
+	if opts.RejectsPath != "" && len(result.Errors) > 0 {
+		if err := writeRejects(opts.RejectsPath, khReader.Header(), result.Errors); err != nil {
+			return fmt.Errorf("failed to write rejects file: %w", err)
 		}
+		fmt.Fprintf(os.Stderr, "Rejected rows written to: %s\n", opts.RejectsPath)
 	}
 
-	fmt.Printf("Successfully converted %d transactions\n", len(ezTransactions))
+	fmt.Printf("Successfully converted %d transactions\n", result.Converted)
 
-	// Write output
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+	for _, line := range inferred.lines {
+		fmt.Println(line)
 	}
-	defer outputFile.Close()
 
-	if err := converter.WriteCSV(outputFile, ezTransactions); err != nil {
+	if err := outputFile.Close(); err != nil {
 		return fmt.Errorf("failed to write CSV: %w", err)
 	}
 
@@ -64,25 +109,107 @@ func ConvertCmd(inputPath, outputPath, accountName, configPath string) error {
 	return nil
 }
 
+// writeRejects writes the raw rows that were skipped, with the export's header, so they can be fixed and converted again
+// Rows that couldn't be split into fields are only reported
+func writeRejects(path string, header []string, rowErrors []*parser.RowError) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Comma = '\t'
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, rowErr := range rowErrors {
+		if rowErr.Record == nil {
+			continue
+		}
+		if err := writer.Write(rowErr.Record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// reportRowErrors warns about rows of the export that were skipped
+func reportRowErrors(rowErrors []*parser.RowError) {
+	if len(rowErrors) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %d row(s) of the export skipped:\n", len(rowErrors))
+	for _, err := range rowErrors {
+		fmt.Fprintf(os.Stderr, "  - %v\n", err)
+	}
+}
+
+// inferredMatches notes partners categorized by similarity or the classifier,
+// so they can be reviewed after the conversion
+type inferredMatches struct {
+	seen  map[string]bool
+	lines []string
+}
+
+func (m *inferredMatches) note(kh *parser.KHTransaction, match categorizer.Match) {
+	if m.seen[kh.PartnerName] {
+		return
+	}
+	m.seen[kh.PartnerName] = true
+
+	switch match.Tier {
+	case "fuzzy":
+		m.lines = append(m.lines, fmt.Sprintf("  ~ \"%s\" matched known partner \"%s\" (score %.2f) → %s / %s",
+			kh.PartnerName, match.Rule, match.Score, match.Category, match.SubCategory))
+	case "model":
+		m.lines = append(m.lines, fmt.Sprintf("  ? \"%s\" classified by model (confidence %.2f) → %s / %s",
+			kh.PartnerName, match.Score, match.Category, match.SubCategory))
+	}
+}
+
+// loadClassifier enables the classifier tier if the config references a model file
+func loadClassifier(cat *categorizer.Categorizer, cfg *config.Config, configPath string) error {
+	model, err := loadModel(cfg, configPath)
+	if err != nil || model == nil {
+		return err
+	}
+
+	cat.SetClassifier(model, cfg.Classifier.MinConfidence)
+	return nil
+}
+
+// loadModel reads the classifier model the config references, nil if there is none
+func loadModel(cfg *config.Config, configPath string) (*classifier.Model, error) {
+	if cfg.Classifier == nil || cfg.Classifier.Model == "" {
+		return nil, nil
+	}
+
+	modelPath := cfg.Classifier.Model
+	if !filepath.IsAbs(modelPath) && configPath != "" {
+		modelPath = filepath.Join(filepath.Dir(configPath), modelPath)
+	}
+
+	return classifier.LoadModel(modelPath)
+}
+
 func loadConfigOrDefault(configPath string) (*config.Config, error) {
 	if configPath == "" {
 		// No config provided, use empty config
-		return &config.Config{
-			KnownPartners: []string{},
-			Categories:    make(map[string]*config.Category),
-		}, nil
+		return config.New(), nil
 	}
 
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			fmt.Fprintf(os.Stderr, "Warning: Config file not found, using empty categories\n")
-			return &config.Config{
-				KnownPartners: []string{},
-				Categories:    make(map[string]*config.Category),
-			}, nil
+			return config.New(), nil
 		}
-		return nil, err
+		return nil, fmt.Errorf("%w (run 'ezbook-convert repair-config --config %s' to fix LLM formatting mistakes)", err, configPath)
 	}
 
 	return cfg, nil
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const convertExport = "könyvelés dátuma\ttranzakció azonosító\ttípus\tkönyvelési számla\tkönyvelési számla elnevezése\t" +
+	"partner számla\tpartner elnevezése\tösszeg\tösszeg devizaneme\tközlemény\n" +
+	"2024.03.01\t1\tKártyás vásárlás\t1040\tFolyószámla\t\tTESCO\t-1 200\tHUF\t\n" +
+	"2024.03.02\t2\tKártyás vásárlás\t1040\tFolyószámla\t\tSPAR\tsok\tHUF\t\n" +
+	"2024.03.03\t3\tKártyás vásárlás\n"
+
+func TestConvertRejects(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "export.tsv")
+	if err := os.WriteFile(input, []byte(convertExport), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ConvertOptions{
+		InputPath:   input,
+		OutputPath:  filepath.Join(dir, "out.csv"),
+		AccountName: "Bank",
+		RejectsPath: filepath.Join(dir, "rejects.tsv"),
+	}
+	stdout := captureStdout(t, func() {
+		if err := ConvertCmd(opts); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// Only the accepted row counts as parsed, the rejects are reported next to it
+	if want := "Parsed 1 transactions from K&H export, 2 row(s) rejected\n"; !strings.HasPrefix(stdout, want) {
+		t.Errorf("stdout = %q, want it to start with %q", stdout, want)
+	}
+
+	output, err := os.ReadFile(opts.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(output)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "TESCO") {
+		t.Errorf("output = %q, want the header and the TESCO row", output)
+	}
+
+	// The rejects file keeps the header and raw rows, so it can be fixed and converted again
+	rejects, err := os.ReadFile(opts.RejectsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(convertExport, "\n")
+	if want := lines[0] + "\n" + lines[2] + "\n" + lines[3] + "\n"; string(rejects) != want {
+		t.Errorf("rejects =\n%s\nwant\n%s", rejects, want)
+	}
+}
+
+func TestConvertStrict(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "export.tsv")
+	if err := os.WriteFile(input, []byte(convertExport), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ConvertOptions{InputPath: input, OutputPath: filepath.Join(dir, "out.csv"), AccountName: "Bank", Strict: true}
+	err := ConvertCmd(opts)
+	if err == nil || !strings.Contains(err.Error(), "export.tsv:3: column 8 (összeg): invalid amount: sok") {
+		t.Errorf("error = %v, want the amount of line 3", err)
+	}
+	// No partial output is left behind
+	if _, err := os.Stat(opts.OutputPath); !os.IsNotExist(err) {
+		t.Errorf("output file exists after a strict failure: %v", err)
+	}
+}
+
+// captureStdout returns what fn prints to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- data
+	}()
+	fn()
+	writer.Close()
+	return string(<-output)
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lines of unchanged context around each change
+const diffContext = 3
+
+// unifiedDiff returns the changes between two texts in unified diff format, "" if they are equal
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	a := strings.Split(strings.TrimSuffix(oldText, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(newText, "\n"), "\n")
+	ops := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	changed := false
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		changed = true
+		hunkStart := max(first-diffContext, start)
+		end := first
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		hunkEnd := min(end+diffContext, len(ops))
+
+		oldStart, newStart, oldCount, newCount := ops[hunkStart].oldLine, ops[hunkStart].newLine, 0, 0
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range ops[hunkStart:hunkEnd] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.text)
+		}
+		start = hunkEnd
+	}
+
+	if !changed {
+		return ""
+	}
+	return out.String()
+}
+
+type diffOp struct {
+	kind             byte // ' ', '-' or '+'
+	text             string
+	oldLine, newLine int // 1-based line numbers before the operation
+}
+
+// diffLines computes a line diff with a longest common subsequence table
+// Common prefix and suffix are skipped first, as migrations usually change a few places
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	oldLine, newLine := 1, 1
+	add := func(kind byte, text string) {
+		ops = append(ops, diffOp{kind, text, oldLine, newLine})
+		if kind != '+' {
+			oldLine++
+		}
+		if kind != '-' {
+			newLine++
+		}
+	}
+
+	for _, line := range a[:prefix] {
+		add(' ', line)
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			add(' ', midA[i])
+			i++
+			j++
+		case i < len(midA) && (j == len(midB) || lcs[i+1][j] >= lcs[i][j+1]):
+			// Removals go before additions, as in diff -u
+			add('-', midA[i])
+			i++
+		default:
+			add('+', midB[j])
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		add(' ', line)
+	}
+	return ops
+}
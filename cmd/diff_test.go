@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := unifiedDiff("a", "b", "same\n", "same\n"); diff != "" {
+		t.Errorf("diff of equal texts = %q, want empty", diff)
+	}
+
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	old := strings.Join(lines, "\n") + "\n"
+	lines[1] = "line two"
+	lines = append(lines[:15], lines[16:]...)
+	lines = append(lines, "line 21")
+	changed := strings.Join(lines, "\n") + "\n"
+
+	want := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++line two
+ line 3
+ line 4
+ line 5
+@@ -13,8 +13,8 @@
+ line 13
+ line 14
+ line 15
+-line 16
+ line 17
+ line 18
+ line 19
+ line 20
++line 21
+`
+	if diff := unifiedDiff("old", "new", old, changed); diff != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", diff, want)
+	}
+}
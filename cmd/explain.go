@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/parser"
+)
+
+// ExplainCmd executes the explain command
+func ExplainCmd(inputPath, configPath, transactionID string) error {
+	cfg, err := loadConfigOrDefault(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	inputFile, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer inputFile.Close()
+
+	khTransactions, rowErrors, err := parser.ParseKHExport(inputFile, inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse K&H export: %w", err)
+	}
+	reportRowErrors(rowErrors)
+
+	cat := categorizer.New(cfg)
+	if err := loadClassifier(cat, cfg, configPath); err != nil {
+		return fmt.Errorf("failed to load classifier model: %w", err)
+	}
+
+	found := false
+	for _, kh := range khTransactions {
+		if transactionID != "" && kh.TransactionID != transactionID {
+			continue
+		}
+		found = true
+
+		matches := cat.Explain(kh.PartnerName, kh.Type, kh.Description, true)
+		printExplanation(kh, matches)
+	}
+
+	if !found {
+		if transactionID != "" {
+			return fmt.Errorf("transaction %s not found in %s", transactionID, inputPath)
+		}
+		fmt.Println("No transactions found.")
+	}
+
+	return nil
+}
+
+func printExplanation(kh *parser.KHTransaction, matches []categorizer.Match) {
+	winner := matches[0]
+
+	// The converter fills in an empty subcategory from the amount sign
+	defaultSubCategory := "Other Income"
+	if strings.HasPrefix(kh.Amount, "-") {
+		defaultSubCategory = "Other Expense"
+	}
+	subCategory := func(m categorizer.Match) string {
+		if m.SubCategory == "" {
+			return defaultSubCategory
+		}
+		return m.SubCategory
+	}
+
+	fmt.Printf("Transaction %s (%s, %s %s)\n", kh.TransactionID, kh.Date, kh.Amount, kh.Currency)
+	fmt.Printf("  Partner:  %s\n", kh.PartnerName)
+	if winner.Merchant != "" && winner.Merchant != kh.PartnerName {
+		fmt.Printf("  Merchant: %s\n", winner.Merchant)
+	}
+	fmt.Printf("  Type:     %s\n", kh.Type)
+	fmt.Printf("  Category: %s / %s\n", winner.Category, subCategory(winner))
+	fmt.Printf("  Rule:     %s\n", describeMatch(winner))
+
+	if len(matches) > 1 {
+		fmt.Println("  Also matched:")
+		for _, m := range matches[1:] {
+			fmt.Printf("    - %s → %s / %s\n", describeMatch(m), m.Category, subCategory(m))
+		}
+	}
+
+	fmt.Println()
+}
+
+// describeMatch formats the tier and rule of a match for display
+func describeMatch(m categorizer.Match) string {
+	switch m.Tier {
+	case "exact":
+		return fmt.Sprintf("exact match \"%s\"", m.Rule)
+	case "keyword":
+		return fmt.Sprintf("keyword \"%s\"", m.Rule)
+	case "fuzzy":
+		return fmt.Sprintf("fuzzy match with known partner \"%s\" (score %.2f)", m.Rule, m.Score)
+	case "model":
+		return fmt.Sprintf("classifier (confidence %.2f)", m.Score)
+	case "type":
+		return fmt.Sprintf("type fallback \"%s\"", m.Rule)
+	default:
+		return "default"
+	}
+}
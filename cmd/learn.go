@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+
+	"ezbook-convert/internal/converter"
+	"ezbook-convert/internal/learner"
+)
+
+// LearnCmd executes the learn command
+func LearnCmd(exportPath string, originalPaths []string, configPath string, write bool) error {
+	cfg, err := loadConfigOrDefault(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	exported, err := readEzBookCSV(exportPath)
+	if err != nil {
+		return err
+	}
+
+	var original []*converter.EzBookTransaction
+	for _, path := range originalPaths {
+		transactions, err := readEzBookCSV(path)
+		if err != nil {
+			return err
+		}
+		original = append(original, transactions...)
+	}
+
+	corrections, unmatched := learner.FindCorrections(original, exported)
+
+	fmt.Printf("Compared %d converted transactions with %d exported transactions\n", len(original), len(exported))
+	if unmatched > 0 {
+		fmt.Printf("Warning: %d converted transactions were not found in the export\n", unmatched)
+	}
+
+	if len(corrections) == 0 {
+		fmt.Println("✓ No category corrections found.")
+		return nil
+	}
+
+	fmt.Printf("\nFound %d corrected transaction(s):\n", len(corrections))
+	for _, c := range corrections {
+		fmt.Printf("  • %s %s \"%s\": %s / %s → %s / %s\n",
+			c.DateTime, c.Amount, c.PartnerName, c.FromCategory, c.FromSubCategory, c.ToCategory, c.ToSubCategory)
+	}
+
+	proposals, skipped := learner.Propose(cfg, corrections)
+
+	if len(skipped) > 0 {
+		fmt.Println("\nSkipped:")
+		for _, reason := range skipped {
+			fmt.Printf("  - %s\n", reason)
+		}
+	}
+
+	if len(proposals) == 0 {
+		fmt.Println("\nNo new rules to propose.")
+		return nil
+	}
+
+	fmt.Println("\nProposed rules:")
+	for _, p := range proposals {
+		suffix := ""
+		if p.NewCategory {
+			suffix = " (new category)"
+		}
+		fmt.Printf("  + %s / %s: exact_matches += \"%s\" (%d correction(s))%s\n",
+			p.Category, p.SubCategory, p.PartnerName, p.Corrections, suffix)
+		for _, name := range p.RemoveFrom {
+			fmt.Printf("  - %s: exact_matches -= \"%s\"\n", name, p.PartnerName)
+			if category := cfg.Categories[name]; len(category.Keywords) == 0 && len(category.ExactMatches) == 1 {
+				fmt.Printf("  - %s (no rules left)\n", name)
+			}
+		}
+	}
+
+	if !write {
+		fmt.Println("\nRun again with --write to save these rules to the config.")
+		return nil
+	}
+
+	if configPath == "" {
+		return fmt.Errorf("--config is required with --write")
+	}
+
+	learner.Apply(cfg, proposals)
+
+	if err := saveConfig(configPath, cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("\n✓ %d rule(s) written to: %s\n", len(proposals), configPath)
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/linter"
+	"ezbook-convert/internal/parser"
+)
+
+// LintCmd executes the lint command
+func LintCmd(configPath, inputPath string) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	issues := linter.Lint(cfg)
+
+	if inputPath != "" {
+		inputFile, err := os.Open(inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to open input file: %w", err)
+		}
+		defer inputFile.Close()
+
+		khTransactions, rowErrors, err := parser.ParseKHExport(inputFile, inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to parse K&H export: %w", err)
+		}
+		reportRowErrors(rowErrors)
+
+		cat := categorizer.New(cfg)
+		if err := loadClassifier(cat, cfg, configPath); err != nil {
+			return fmt.Errorf("failed to load classifier model: %w", err)
+		}
+
+		issues = append(issues, linter.LintUsage(cfg, cat, khTransactions)...)
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("✓ No problems found in %s\n", configPath)
+		return nil
+	}
+
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.Severity == "error" {
+			errorCount++
+		}
+		fmt.Printf("%s: %s\n", configPath, issue)
+	}
+
+	fmt.Printf("\n%d problem(s) found (%d error(s), %d warning(s))\n", len(issues), errorCount, len(issues)-errorCount)
+
+	if errorCount > 0 {
+		return fmt.Errorf("config has %d error(s)", errorCount)
+	}
+
+	return nil
+}
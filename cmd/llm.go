@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"text/template"
+
+	"ezbook-convert/internal/anonymizer"
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/llm"
+)
+
+// queryLLM sends each prompt part to an OpenAI-compatible server and merges the answers into the config
+// Nothing is written unless every answer parses; personal data in the prompts is already replaced by placeholders
+func queryLLM(opts UpdateConfigOptions, tmpl *template.Template, parts []*PromptData, cfg *config.Config, mapping *anonymizer.Mapping) error {
+	prompts, err := renderPrompts(tmpl, parts)
+	if err != nil {
+		return err
+	}
+
+	client := llm.NewClient(opts.LLMEndpoint, opts.LLMModel)
+	client.APIKey = opts.LLMAPIKey
+
+	var responses []*config.Config
+	for i, prompt := range prompts {
+		name := "LLM response"
+		if len(prompts) > 1 {
+			name = fmt.Sprintf("LLM response %d/%d", i+1, len(prompts))
+		}
+
+		fmt.Printf("Sending prompt %d/%d to %s (%s, ~%d tokens)...\n", i+1, len(prompts), client.Endpoint, client.Model, estimateTokens(string(prompt)))
+		answer, err := client.Complete(context.Background(), string(prompt))
+		if err != nil {
+			return err
+		}
+
+		response, err := parseResponse(name, []byte(answer), cfg, mapping, opts.MappingPath)
+		if err != nil {
+			return err
+		}
+		responses = append(responses, response)
+	}
+	fmt.Println()
+
+	return applyResponses(cfg, opts.ConfigPath, responses, false)
+}
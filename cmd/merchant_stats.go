@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/parser"
+)
+
+// merchantStats summarizes the transactions of one partner as context for the LLM
+type merchantStats struct {
+	Count    int
+	Expenses int
+	Incomes  int
+	Total    float64 // Sum of absolute amounts
+	Currency string
+
+	amounts []float64
+	types   map[string]int
+}
+
+// collectMerchantStats groups transactions by canonical partner name
+func collectMerchantStats(transactions []*parser.KHTransaction, cat *categorizer.Categorizer) map[string]*merchantStats {
+	stats := make(map[string]*merchantStats)
+	for _, t := range transactions {
+		if t.PartnerName == "" {
+			continue
+		}
+		name := cat.Canonicalize(t.PartnerName)
+		s, ok := stats[name]
+		if !ok {
+			s = &merchantStats{}
+			stats[name] = s
+		}
+		s.add(t)
+	}
+	return stats
+}
+
+func (s *merchantStats) add(t *parser.KHTransaction) {
+	s.Count++
+	if t.Type != "" {
+		if s.types == nil {
+			s.types = make(map[string]int)
+		}
+		s.types[t.Type]++
+	}
+	if s.Currency == "" {
+		s.Currency = t.Currency
+	}
+
+	amount, err := parser.ParseAmount(t.Amount)
+	if err != nil {
+		return
+	}
+	if amount > 0 {
+		s.Incomes++
+	} else {
+		s.Expenses++
+	}
+	s.Total += math.Abs(amount)
+	s.amounts = append(s.amounts, math.Abs(amount))
+}
+
+// merge adds the transactions of another partner, e.g. several spellings of the owner's name
+func (s *merchantStats) merge(other *merchantStats) {
+	if other == nil {
+		return
+	}
+	s.Count += other.Count
+	s.Expenses += other.Expenses
+	s.Incomes += other.Incomes
+	s.Total += other.Total
+	s.amounts = append(s.amounts, other.amounts...)
+	if s.Currency == "" {
+		s.Currency = other.Currency
+	}
+	for t, n := range other.types {
+		if s.types == nil {
+			s.types = make(map[string]int)
+		}
+		s.types[t] += n
+	}
+}
+
+// Typical returns the median absolute amount
+func (s *merchantStats) Typical() float64 {
+	if len(s.amounts) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), s.amounts...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// Direction is "expense", "income" or "expense and income"
+func (s *merchantStats) Direction() string {
+	switch {
+	case s.Expenses > 0 && s.Incomes > 0:
+		return "expense and income"
+	case s.Incomes > 0:
+		return "income"
+	case s.Expenses > 0:
+		return "expense"
+	}
+	return ""
+}
+
+// Types returns the K&H transaction types, most frequent first
+func (s *merchantStats) Types() []string {
+	var types []string
+	for t := range s.types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if s.types[types[i]] != s.types[types[j]] {
+			return s.types[types[i]] > s.types[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// mainType returns the most frequent K&H transaction type
+func (s *merchantStats) mainType() string {
+	if s == nil {
+		return ""
+	}
+	if types := s.Types(); len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// Summary formats the stats for the prompt, e.g. "3× expense, total 12,600 HUF, typical 4,200 HUF, K&H type: Vásárlás"
+func (s *merchantStats) Summary() string {
+	if s == nil || s.Count == 0 {
+		return "no transactions"
+	}
+
+	parts := []string{fmt.Sprintf("%d×", s.Count)}
+	if direction := s.Direction(); direction != "" {
+		parts[0] += " " + direction
+		parts = append(parts,
+			"total "+formatPromptAmount(s.Total, s.Currency),
+			"typical "+formatPromptAmount(s.Typical(), s.Currency))
+	}
+	if types := s.Types(); len(types) > 0 {
+		parts = append(parts, "K&H type: "+strings.Join(types, " / "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatPromptAmount rounds to whole units with thousands separators
+func formatPromptAmount(amount float64, currency string) string {
+	digits := fmt.Sprintf("%.0f", amount)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteRune(',')
+		}
+		b.WriteRune(r)
+	}
+	if currency != "" {
+		b.WriteString(" " + currency)
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/parser"
+)
+
+func TestMerchantStats(t *testing.T) {
+	cfg := config.New()
+	cfg.Merchants = []*config.Merchant{{Name: "Tesco", Patterns: []string{"^tesco"}}}
+
+	transactions := []*parser.KHTransaction{
+		{PartnerName: "TESCO ÁRUHÁZ 41028", Type: "Vásárlás", Amount: "-4200", Currency: "HUF"},
+		{PartnerName: "TESCO EXPRESS", Type: "Vásárlás", Amount: "-1200", Currency: "HUF"},
+		{PartnerName: "TESCO ÁRUHÁZ 41028", Type: "Jóváírás", Amount: "7200", Currency: "HUF"},
+		{PartnerName: "", Type: "Díj", Amount: "-100", Currency: "HUF"},
+	}
+
+	stats := collectMerchantStats(transactions, categorizer.New(cfg))
+	if len(stats) != 1 {
+		t.Fatalf("stats for %d merchants, want only Tesco", len(stats))
+	}
+
+	tesco := stats["Tesco"]
+	want := "3× expense and income, total 12,600 HUF, typical 4,200 HUF, K&H type: Vásárlás / Jóváírás"
+	if got := tesco.Summary(); got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+	if tesco.mainType() != "Vásárlás" {
+		t.Errorf("mainType = %q, want Vásárlás", tesco.mainType())
+	}
+
+	owner := &merchantStats{}
+	owner.merge(tesco)
+	owner.merge(nil)
+	if owner.Count != 3 || owner.Typical() != 4200 {
+		t.Errorf("merged stats = %+v", owner)
+	}
+
+	if got := (*merchantStats)(nil).Summary(); got != "no transactions" {
+		t.Errorf("nil Summary = %q", got)
+	}
+}
+
+func TestFormatPromptAmount(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     string
+	}{
+		{0, "", "0"},
+		{999.6, "HUF", "1,000 HUF"},
+		{1234567, "EUR", "1,234,567 EUR"},
+	}
+	for _, tt := range tests {
+		if got := formatPromptAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("formatPromptAmount(%v, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
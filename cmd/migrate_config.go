@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"ezbook-convert/internal/config"
+)
+
+// MigrateConfigCmd executes the migrate-config command
+// It shows the changes needed to bring the config to the current layout and saves them with write
+func MigrateConfigCmd(configPath string, write bool) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	migrated, from, steps, err := config.Migrate(configPath, data)
+	if err != nil {
+		return fmt.Errorf("failed to migrate config: %w", err)
+	}
+
+	if len(steps) == 0 {
+		fmt.Printf("✓ %s is already at version %d\n", configPath, from)
+		return nil
+	}
+
+	fmt.Printf("Migrating %s from version %d to %d:\n", configPath, from, config.CurrentVersion)
+	for _, step := range steps {
+		fmt.Printf("  • %s\n", step)
+	}
+	fmt.Println()
+	fmt.Print(unifiedDiff(configPath, configPath+" (migrated)", string(data), string(migrated)))
+
+	if !write {
+		fmt.Println("\nRun again with --write to save the migrated config.")
+		return nil
+	}
+
+	if err := config.WriteFile(configPath, migrated); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("\n✓ Config migrated: %s (previous version saved as %s.bak)\n", configPath, configPath)
+	return nil
+}
+
+// saveConfig saves the config and tells the user if the file was migrated to the current layout on the way
+func saveConfig(path string, cfg *config.Config) error {
+	steps, err := config.SaveConfig(path, cfg)
+	if err != nil {
+		return err
+	}
+	if len(steps) > 0 {
+		fmt.Printf("Note: %s was migrated to config version %d (previous version saved as %s.bak):\n", path, config.CurrentVersion, path)
+		for _, step := range steps {
+			fmt.Printf("  • %s\n", step)
+		}
+	}
+	return nil
+}
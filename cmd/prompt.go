@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"ezbook-convert/internal/anonymizer"
+	"ezbook-convert/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed templates/*.tmpl
+var promptTemplates embed.FS
+
+// PromptData is passed to the LLM prompt template (built-in or --template)
+type PromptData struct {
+	CurrentConfigYAML   string           // Current config without owner names, personal data replaced by placeholders
+	ExistingCategories  string           // "- Category / Subcategory" lines, used instead of the full config in delta prompts
+	Delta               bool             // Batched prompt asking only for new rules
+	Part                int              // Batch number, starting at 1
+	Parts               int              // Number of batches
+	Owners              []PromptMerchant // Spellings of the account owners' names ([OWNER_n])
+	TransferPartners    []PromptMerchant // Private persons ([PERSON_n])
+	AccountNumbers      []PromptMerchant // Bank accounts ([ACCOUNT_n])
+	Businesses          []PromptMerchant // Everything else
+	Merchants           []PromptMerchant // All of the above in prompt order
+	TotalMerchants      int
+	AnonymizedCount     int
+	AvailableCategories string // Comma-separated ezBookkeeping default expense categories
+}
+
+// PromptMerchant is an anonymized unknown merchant with its spend context
+type PromptMerchant struct {
+	Index int            // Position in the prompt, starting at 1
+	Name  string         // Merchant name or placeholder
+	Kind  string         // Anonymizer detection type: "owner_name", "transfer_partner", "personal_name", "account_number", "none", ...
+	Note  string         // First transaction note, anonymized
+	Stats *merchantStats // Count, Expenses, Incomes, Total, Typical, Currency, Direction, Types, Summary
+}
+
+// buildPromptData groups anonymized merchants and numbers them in prompt order
+func buildPromptData(cfg *config.Config, anonCfg *anonymizer.Config, anonymized []anonymizer.AnonymizationResult, notes map[string]string, stats map[string]*merchantStats) (*PromptData, error) {
+	// Serialize current config to YAML, without the owner names, local include paths and type rules
+	yamlData, err := yaml.Marshal(anonymizeConfig(cfg, anonCfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize config: %w", err)
+	}
+
+	data := &PromptData{
+		CurrentConfigYAML:   string(yamlData),
+		ExistingCategories:  describeCategories(cfg),
+		AvailableCategories: getAvailableCategories(),
+		Part:                1,
+		Parts:               1,
+	}
+
+	// Merchants sharing a placeholder are combined, e.g. owner spellings without a mapping ([OWNER_NAME])
+	var merchants []PromptMerchant
+	byName := make(map[string]int)
+	for _, result := range anonymized {
+		merchant := PromptMerchant{
+			Name:  result.Anonymized,
+			Kind:  result.DetectionType,
+			Note:  notes[result.Original],
+			Stats: stats[result.Original],
+		}
+		if merchant.Stats == nil {
+			merchant.Stats = &merchantStats{}
+		}
+
+		if i, ok := byName[merchant.Name]; ok && result.IsPersonal {
+			combined := &merchantStats{}
+			combined.merge(merchants[i].Stats)
+			combined.merge(merchant.Stats)
+			merchants[i].Stats = combined
+			continue
+		}
+		byName[merchant.Name] = len(merchants)
+		merchants = append(merchants, merchant)
+	}
+
+	data.setMerchants(merchants)
+	return data, nil
+}
+
+// anonymizeConfig copies the config for the prompt, replacing known partners and exact matches
+// that are personal data with placeholders from the mapping, --apply translates them back
+// Exact matches often come from categorize or learn, so every value is checked, not only the mapped ones
+func anonymizeConfig(cfg *config.Config, anonCfg *anonymizer.Config) *config.Config {
+	anonymize := func(values []string) []string {
+		var result []string
+		for _, value := range values {
+			result = append(result, anonymizer.AnonymizeValue(value, anonCfg))
+		}
+		return result
+	}
+
+	promptCfg := *cfg
+	promptCfg.Version = 0
+	promptCfg.Owners = nil
+	promptCfg.Include = nil
+	promptCfg.TypeRules = nil
+	promptCfg.KnownPartners = anonymize(cfg.KnownPartners)
+	promptCfg.Categories = make(map[string]*config.Category, len(cfg.Categories))
+	// In name order, so new placeholders are numbered the same way on every run
+	names := make([]string, 0, len(cfg.Categories))
+	for name := range cfg.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		category := cfg.Categories[name]
+		if category == nil {
+			promptCfg.Categories[name] = nil
+			continue
+		}
+		copied := *category
+		copied.ExactMatches = anonymize(category.ExactMatches)
+		promptCfg.Categories[name] = &copied
+	}
+	return &promptCfg
+}
+
+// setMerchants groups merchants by anonymization type and numbers the groups consecutively
+func (data *PromptData) setMerchants(merchants []PromptMerchant) {
+	data.Owners = nil
+	data.TransferPartners, data.AccountNumbers, data.Businesses, data.Merchants = nil, nil, nil, nil
+
+	for _, merchant := range merchants {
+		switch merchant.Kind {
+		case "owner_name":
+			data.Owners = append(data.Owners, merchant)
+		case "transfer_partner", "personal_name":
+			data.TransferPartners = append(data.TransferPartners, merchant)
+		case "account_number":
+			data.AccountNumbers = append(data.AccountNumbers, merchant)
+		default:
+			data.Businesses = append(data.Businesses, merchant)
+		}
+	}
+
+	// Each group continues the numbering after the previous one
+	index := 0
+	next := func(merchants []PromptMerchant) {
+		for i := range merchants {
+			index++
+			merchants[i].Index = index
+			data.Merchants = append(data.Merchants, merchants[i])
+		}
+	}
+	next(data.Owners)
+	next(data.TransferPartners)
+	next(data.AccountNumbers)
+	next(data.Businesses)
+
+	data.TotalMerchants = len(data.Merchants)
+	data.AnonymizedCount = len(data.Owners) + len(data.TransferPartners) + len(data.AccountNumbers)
+}
+
+// splitPrompt batches the merchants so every rendered prompt stays within maxTokens
+// A single prompt asks for the complete config; batches only ask for a YAML delta
+// and list the existing categories instead of embedding the whole config
+func splitPrompt(data *PromptData, tmpl *template.Template, maxTokens int) ([]*PromptData, error) {
+	if maxTokens <= 0 {
+		return []*PromptData{data}, nil
+	}
+
+	tokens, err := renderedTokens(tmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	if tokens <= maxTokens {
+		return []*PromptData{data}, nil
+	}
+
+	newPart := func(merchants []PromptMerchant) *PromptData {
+		part := *data
+		part.Delta = true
+		part.setMerchants(append([]PromptMerchant(nil), merchants...))
+		return &part
+	}
+
+	// A template without a .Delta branch would ask every part for the complete config,
+	// and the answers would overwrite each other on --apply
+	var full, delta bytes.Buffer
+	if err := tmpl.Execute(&full, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	if err := tmpl.Execute(&delta, newPart(data.Merchants)); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	if full.String() == delta.String() {
+		return nil, fmt.Errorf("the prompt needs ~%d tokens but the template ignores .Delta, so it can't be split into parts; "+
+			"add an {{if .Delta}} branch asking only for new rules or raise --max-tokens", tokens)
+	}
+
+	// Greedily add merchants while the rendered prompt fits; a single oversized merchant gets its own part
+	var parts []*PromptData
+	var batch []PromptMerchant
+	for _, merchant := range data.Merchants {
+		candidate := append(append([]PromptMerchant(nil), batch...), merchant)
+		tokens, err := renderedTokens(tmpl, newPart(candidate))
+		if err != nil {
+			return nil, err
+		}
+		if tokens > maxTokens && len(batch) > 0 {
+			parts = append(parts, newPart(batch))
+			batch = []PromptMerchant{merchant}
+			continue
+		}
+		batch = candidate
+	}
+	if len(batch) > 0 {
+		parts = append(parts, newPart(batch))
+	}
+
+	for i, part := range parts {
+		part.Part = i + 1
+		part.Parts = len(parts)
+	}
+	return parts, nil
+}
+
+// renderedTokens estimates the token count of the rendered prompt
+func renderedTokens(tmpl *template.Template, data *PromptData) (int, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return 0, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return estimateTokens(b.String()), nil
+}
+
+// estimateTokens approximates LLM tokens as one per four characters
+// Accented Hungarian text tokenizes worse than English, so this errs on the low side for it
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// describeCategories lists the category and subcategory names, one per line
+func describeCategories(cfg *config.Config) string {
+	var names []string
+	for name := range cfg.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString("- " + name)
+		if category := cfg.Categories[name]; category != nil && category.SubCategory != "" {
+			b.WriteString(" / " + category.SubCategory)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// loadPromptTemplate parses a built-in template ("en", "hu") or a template file
+func loadPromptTemplate(name string) (*template.Template, error) {
+	var text []byte
+	var err error
+	switch name {
+	case "", "en", "hu":
+		if name == "" {
+			name = "en"
+		}
+		text, err = promptTemplates.ReadFile("templates/prompt_" + name + ".tmpl")
+	default:
+		text, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New("prompt").Funcs(template.FuncMap{
+		"amount": formatPromptAmount,
+		"join":   strings.Join,
+	}).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// jsonMerchant is one entry of the --format json listing
+type jsonMerchant struct {
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	Note      string   `json:"note,omitempty"`
+	Count     int      `json:"count"`
+	Direction string   `json:"direction,omitempty"`
+	Total     float64  `json:"total"`
+	Typical   float64  `json:"typical"`
+	Currency  string   `json:"currency,omitempty"`
+	Types     []string `json:"types,omitempty"`
+}
+
+// writeMerchantsJSON writes the unknown merchants as a JSON array in prompt order
+func writeMerchantsJSON(w io.Writer, data *PromptData) error {
+	merchants := make([]jsonMerchant, 0, len(data.Merchants))
+	for _, m := range data.Merchants {
+		merchants = append(merchants, jsonMerchant{
+			Name:      m.Name,
+			Kind:      m.Kind,
+			Note:      m.Note,
+			Count:     m.Stats.Count,
+			Direction: m.Stats.Direction(),
+			Total:     m.Stats.Total,
+			Typical:   m.Stats.Typical(),
+			Currency:  m.Stats.Currency,
+			Types:     m.Stats.Types(),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(merchants)
+}
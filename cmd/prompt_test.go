@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+
+	"ezbook-convert/internal/anonymizer"
+	"ezbook-convert/internal/config"
+)
+
+func TestBuildPromptDataAnonymizesConfig(t *testing.T) {
+	mapping := anonymizer.NewMapping()
+	person := mapping.Placeholder("PERSON", "Nagy Anna")
+	account := mapping.Placeholder("ACCOUNT", "HU42117730161111101800000000")
+	anonCfg := &anonymizer.Config{OwnerNames: []string{"Kovács Béla"}, Mapping: mapping}
+
+	cfg := config.New()
+	cfg.Owners = []string{"Kovács Béla"}
+	cfg.KnownPartners = []string{"TESCO", "Nagy Anna", "KOVACS BELA", "HU42117730161111101800000000", "Kiss Péter"}
+	cfg.Categories["Housing & Houseware"] = &config.Category{
+		SubCategory:  "Rent",
+		ExactMatches: []string{"Nagy Anna", "HU42117730161111101800000000"},
+	}
+	// Added by categorize or learn, so not in the mapping yet
+	cfg.Categories["Miscellaneous"] = &config.Category{
+		SubCategory:  "Other Expense",
+		ExactMatches: []string{"Kiss Péter", "SIMPLEP kisspeter", "KOVACS BELA"},
+	}
+	cfg.Categories["Food & Drink"] = &config.Category{SubCategory: "Groceries", Keywords: []string{"tesco"}}
+
+	data, err := buildPromptData(cfg, anonCfg, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, personal := range []string{"Nagy", "Kovács", "KOVACS", "HU42", "Kiss", "kisspeter"} {
+		if strings.Contains(data.CurrentConfigYAML, personal) {
+			t.Errorf("prompt config contains %q:\n%s", personal, data.CurrentConfigYAML)
+		}
+	}
+	for _, want := range []string{person, account, "[OWNER_1]", "[PERSON_2]", "[PERSON_3]", "TESCO"} {
+		if !strings.Contains(data.CurrentConfigYAML, want) {
+			t.Errorf("prompt config does not contain %q:\n%s", want, data.CurrentConfigYAML)
+		}
+	}
+	if original, ok := mapping.Resolve("[PERSON_3]"); !ok || original != "SIMPLEP kisspeter" {
+		t.Errorf("mapping [PERSON_3] = %q, %v, want SIMPLEP kisspeter", original, ok)
+	}
+
+	// The config itself is left alone
+	if cfg.KnownPartners[1] != "Nagy Anna" || cfg.Categories["Housing & Houseware"].ExactMatches[0] != "Nagy Anna" {
+		t.Errorf("buildPromptData modified the config: %+v", cfg)
+	}
+}
+
+func TestOwnerPlaceholderRoundTrip(t *testing.T) {
+	mapping := anonymizer.NewMapping()
+	anonCfg := &anonymizer.Config{OwnerNames: []string{"Vigh Dániel"}, Mapping: mapping}
+
+	base := config.New()
+	base.KnownPartners = []string{"VIGH DANIEL"}
+	base.Categories["Miscellaneous"] = &config.Category{SubCategory: "Other Income", ExactMatches: []string{"VIGH DANIEL"}}
+
+	anonymized := []anonymizer.AnonymizationResult{anonymizer.Anonymize("Vigh Dániel", "Átutalás jóváírás", anonCfg)}
+	data, err := buildPromptData(base, anonCfg, anonymized, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Owners) != 1 || data.Owners[0].Name != "[OWNER_1]" || !strings.Contains(data.CurrentConfigYAML, "[OWNER_2]") {
+		t.Fatalf("owners = %+v, config:\n%s", data.Owners, data.CurrentConfigYAML)
+	}
+
+	// The LLM answers with the placeholders of the prompt
+	response := &config.Config{
+		KnownPartners: []string{"[OWNER_1]", "[OWNER_2]"},
+		Categories: map[string]*config.Category{
+			"Miscellaneous": {SubCategory: "Other Income", ExactMatches: []string{"[OWNER_1]", "[OWNER_2]"}},
+		},
+	}
+	translatePlaceholders(response, mapping)
+	if unresolved := unresolvedPlaceholders(response, base); len(unresolved) != 0 {
+		t.Fatalf("unresolved placeholders %q", unresolved)
+	}
+
+	merged, _ := config.Merge(base, response, true)
+	if want := []string{"VIGH DANIEL", "Vigh Dániel"}; !reflect.DeepEqual(merged.Categories["Miscellaneous"].ExactMatches, want) {
+		t.Errorf("exact matches = %q, want %q", merged.Categories["Miscellaneous"].ExactMatches, want)
+	}
+	if want := []string{"VIGH DANIEL", "Vigh Dániel"}; !reflect.DeepEqual(merged.KnownPartners, want) {
+		t.Errorf("known_partners = %q, want %q", merged.KnownPartners, want)
+	}
+}
+
+func TestUnresolvedPlaceholders(t *testing.T) {
+	base := config.New()
+	base.KnownPartners = []string{"[TRANSFER_PARTNER]", "[OWNER_NAME]"}
+
+	response := &config.Config{
+		KnownPartners: []string{"[TRANSFER_PARTNER]", "[OWNER_NAME]", "TESCO"},
+		Categories: map[string]*config.Category{
+			"Miscellaneous": {SubCategory: "Other Income", ExactMatches: []string{"[OWNER_NAME] 2", "[PERSON_9]"}},
+			"Food & Drink":  {SubCategory: "Groceries", Keywords: []string{"[PERSON_9]"}},
+		},
+	}
+	// Generic placeholders already in the config are left alone
+	if got, want := unresolvedPlaceholders(response, base), []string{"[OWNER_NAME]", "[PERSON_9]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unresolved = %q, want %q", got, want)
+	}
+}
+
+func TestTranslatePlaceholders(t *testing.T) {
+	mapping := anonymizer.NewMapping()
+	person := mapping.Placeholder("PERSON", "Nagy Anna")
+
+	response := &config.Config{
+		KnownPartners: []string{person, "TESCO", "[PERSON_9]"},
+		Categories: map[string]*config.Category{
+			"Housing & Houseware": {SubCategory: "Rent", Keywords: []string{person, "albérlet"}},
+			"Empty":               nil,
+		},
+	}
+
+	if translated := translatePlaceholders(response, mapping); translated != 2 {
+		t.Errorf("translated = %d, want 2", translated)
+	}
+	if want := []string{"Nagy Anna", "TESCO", "[PERSON_9]"}; !reflect.DeepEqual(response.KnownPartners, want) {
+		t.Errorf("known_partners = %q, want %q", response.KnownPartners, want)
+	}
+	// A placeholder used as keyword becomes an exact match of the real name
+	category := response.Categories["Housing & Houseware"]
+	if !reflect.DeepEqual(category.Keywords, []string{"albérlet"}) || !reflect.DeepEqual(category.ExactMatches, []string{"Nagy Anna"}) {
+		t.Errorf("category = %+v", category)
+	}
+}
+
+func promptTestData(t *testing.T) *PromptData {
+	t.Helper()
+	anonymized := []anonymizer.AnonymizationResult{
+		{Original: "TESCO", Anonymized: "TESCO", DetectionType: "none"},
+		{Original: "Kovács Béla", Anonymized: "[OWNER_NAME]", IsPersonal: true, DetectionType: "owner_name"},
+		{Original: "Nagy Anna", Anonymized: "[PERSON_1]", IsPersonal: true, DetectionType: "transfer_partner"},
+		{Original: "KOVACS BELA", Anonymized: "[OWNER_NAME]", IsPersonal: true, DetectionType: "owner_name"},
+		{Original: "HU42117730161111101800000000", Anonymized: "[ACCOUNT_1]", IsPersonal: true, DetectionType: "account_number"},
+	}
+	stats := map[string]*merchantStats{
+		"TESCO":       {Count: 2, Expenses: 2, Total: 5400, Currency: "HUF", amounts: []float64{4200, 1200}},
+		"Kovács Béla": {Count: 1, Incomes: 1, Total: 100000, Currency: "HUF", amounts: []float64{100000}},
+		"KOVACS BELA": {Count: 1, Expenses: 1, Total: 20000, Currency: "HUF", amounts: []float64{20000}},
+	}
+
+	data, err := buildPromptData(config.New(), nil, anonymized, map[string]string{"TESCO": "Bevásárlás"}, stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBuildPromptDataGroups(t *testing.T) {
+	data := promptTestData(t)
+
+	var got []string
+	for _, m := range data.Merchants {
+		got = append(got, fmt.Sprintf("%d %s", m.Index, m.Name))
+	}
+	want := []string{"1 [OWNER_NAME]", "2 [PERSON_1]", "3 [ACCOUNT_1]", "4 TESCO"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merchants = %q, want %q", got, want)
+	}
+	// Without a mapping both owner spellings share the generic placeholder and are combined
+	if len(data.Owners) != 1 || data.Owners[0].Stats.Count != 2 || data.Owners[0].Stats.Direction() != "expense and income" {
+		t.Errorf("owners = %+v, want both owner spellings combined", data.Owners)
+	}
+	if data.TotalMerchants != 4 || data.AnonymizedCount != 3 {
+		t.Errorf("TotalMerchants = %d, AnonymizedCount = %d, want 4 and 3", data.TotalMerchants, data.AnonymizedCount)
+	}
+}
+
+func TestPromptTemplates(t *testing.T) {
+	data := promptTestData(t)
+
+	for _, name := range []string{"en", "hu"} {
+		tmpl, err := loadPromptTemplate(name)
+		if err != nil {
+			t.Fatalf("loadPromptTemplate(%s): %v", name, err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			t.Fatalf("template %s: %v", name, err)
+		}
+		for _, want := range []string{"[OWNER_NAME]", "[PERSON_1]", "[ACCOUNT_1]", "TESCO", "Bevásárlás", "5,400 HUF"} {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("template %s does not mention %q", name, want)
+			}
+		}
+	}
+
+	if _, err := loadPromptTemplate("does-not-exist.tmpl"); err == nil {
+		t.Error("loadPromptTemplate of a missing file should fail")
+	}
+}
+
+func TestWriteMerchantsJSON(t *testing.T) {
+	var b strings.Builder
+	if err := writeMerchantsJSON(&b, promptTestData(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	var merchants []jsonMerchant
+	if err := json.Unmarshal([]byte(b.String()), &merchants); err != nil {
+		t.Fatal(err)
+	}
+	if len(merchants) != 4 {
+		t.Fatalf("%d merchants, want 4", len(merchants))
+	}
+	if m := merchants[3]; m.Name != "TESCO" || m.Count != 2 || m.Typical != 2700 || m.Direction != "expense" || m.Note != "Bevásárlás" {
+		t.Errorf("TESCO = %+v", m)
+	}
+}
+
+func TestSplitPrompt(t *testing.T) {
+	tmpl, err := loadPromptTemplate("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := &PromptData{CurrentConfigYAML: "categories: {}\n", Part: 1, Parts: 1}
+	var merchants []PromptMerchant
+	for i := 0; i < 60; i++ {
+		merchants = append(merchants, PromptMerchant{
+			Name:  fmt.Sprintf("MERCHANT %02d WITH A LONG NAME", i),
+			Kind:  "none",
+			Stats: &merchantStats{Count: 1},
+		})
+	}
+	data.setMerchants(merchants)
+
+	whole, err := renderedTokens(tmpl, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts, _ := splitPrompt(data, tmpl, whole); len(parts) != 1 || parts[0].Delta {
+		t.Fatalf("splitPrompt within the limit = %d parts, want the full prompt", len(parts))
+	}
+
+	// Leave room for the delta instructions plus about a quarter of the merchants
+	single := *data
+	single.Delta = true
+	single.setMerchants(merchants[:1])
+	base, err := renderedTokens(tmpl, &single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxTokens := base + (whole-base)/4
+	parts, err := splitPrompt(data, tmpl, maxTokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) < 2 {
+		t.Fatalf("splitPrompt = %d parts, want several", len(parts))
+	}
+
+	seen := 0
+	for i, part := range parts {
+		if !part.Delta || part.Part != i+1 || part.Parts != len(parts) {
+			t.Errorf("part %d: Delta=%v Part=%d Parts=%d", i+1, part.Delta, part.Part, part.Parts)
+		}
+		if tokens, _ := renderedTokens(tmpl, part); tokens > maxTokens {
+			t.Errorf("part %d has %d tokens, limit %d", i+1, tokens, maxTokens)
+		}
+		// Numbering restarts in every part
+		if part.Merchants[0].Index != 1 || part.Merchants[0].Name != merchants[seen].Name {
+			t.Errorf("part %d starts with %+v, want %s as 1", i+1, part.Merchants[0], merchants[seen].Name)
+		}
+		seen += len(part.Merchants)
+	}
+	if seen != len(merchants) {
+		t.Errorf("parts contain %d merchants, want %d", seen, len(merchants))
+	}
+
+	// A custom template without a .Delta branch can't be split
+	custom, err := template.New("prompt").Parse("{{.CurrentConfigYAML}}{{range .Merchants}}{{.Index}}. {{.Name}}\n{{end}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := splitPrompt(data, custom, 50); err == nil || !strings.Contains(err.Error(), "ignores .Delta") {
+		t.Errorf("splitPrompt with a template ignoring .Delta: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+
+	"ezbook-convert/internal/config"
+)
+
+// RepairConfigCmd executes the repair-config command
+func RepairConfigCmd(configPath, outputPath string) error {
+	cfg, fixes, err := config.LoadConfigTolerant(configPath)
+	if len(fixes) > 0 {
+		fmt.Printf("Applied %d fix(es) to %s:\n", len(fixes), configPath)
+		for _, fix := range fixes {
+			fmt.Printf("  • %s\n", fix)
+		}
+		fmt.Println()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to repair config: %w", err)
+	}
+
+	if len(fixes) == 0 {
+		fmt.Printf("✓ %s needs no repair\n", configPath)
+		if outputPath == configPath {
+			return nil
+		}
+	}
+
+	if err := saveConfig(outputPath, cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("✓ Clean config written to: %s\n", outputPath)
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"ezbook-convert/internal/config"
+)
+
+// SchemaCmd executes the schema command
+// It prints the JSON Schema of the config file, or writes it to outputPath
+func SchemaCmd(outputPath string) error {
+	if outputPath == "" {
+		_, err := os.Stdout.Write(config.Schema)
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, config.Schema, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+
+	fmt.Printf("✓ JSON Schema written to: %s\n", outputPath)
+	return nil
+}
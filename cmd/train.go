@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"ezbook-convert/internal/classifier"
+	"ezbook-convert/internal/converter"
+)
+
+// TrainCmd executes the train command
+func TrainCmd(inputPaths []string, modelPath string) error {
+	var examples []classifier.Example
+
+	for _, inputPath := range inputPaths {
+		transactions, err := readEzBookCSV(inputPath)
+		if err != nil {
+			return err
+		}
+
+		count := 0
+		for _, t := range transactions {
+			if isDefaultCategory(t.Category, t.SubCategory) {
+				continue // Uncategorized rows would only teach the fallback
+			}
+
+			partnerName, transactionType, note := converter.ParseDescription(t.Description)
+			examples = append(examples, classifier.Example{
+				Category:    t.Category,
+				SubCategory: t.SubCategory,
+				PartnerName: partnerName,
+				Type:        transactionType,
+				Description: note,
+			})
+			count++
+		}
+
+		fmt.Printf("Read %d categorized transactions from %s\n", count, inputPath)
+	}
+
+	if len(examples) == 0 {
+		return fmt.Errorf("no categorized transactions found for training")
+	}
+
+	model := classifier.Train(examples)
+
+	if err := classifier.SaveModel(modelPath, model); err != nil {
+		return fmt.Errorf("failed to save model: %w", err)
+	}
+
+	fmt.Printf("\n✓ Trained model on %d transactions (%d categories, %d features)\n",
+		model.Documents, len(model.Classes), model.Vocabulary)
+	fmt.Printf("Model written to: %s\n", modelPath)
+	fmt.Println("\nEnable it in your config:")
+	fmt.Printf("  classifier:\n    model: %s\n    min_confidence: 0.7\n", modelPath)
+
+	return nil
+}
+
+func readEzBookCSV(path string) ([]*converter.EzBookTransaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer file.Close()
+
+	transactions, err := converter.ReadCSV(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return transactions, nil
+}
+
+// isDefaultCategory reports whether the converter's default fallback assigned this category
+func isDefaultCategory(category, subCategory string) bool {
+	return category == "Miscellaneous" && (subCategory == "Other Expense" || subCategory == "Other Income")
+}
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"text/template"
 
@@ -10,123 +14,105 @@ import (
 	"ezbook-convert/internal/categorizer"
 	"ezbook-convert/internal/config"
 	"ezbook-convert/internal/parser"
-	"gopkg.in/yaml.v3"
+	"golang.org/x/term"
 )
 
-const llmPromptTemplate = `=== PROMPT FOR LLM ===
+const promptHeader = `=== PROMPT%s FOR LLM ===
 Copy the text below and paste it into ChatGPT/Gemini:
 
 ---
-I have a transaction categorization config in YAML format for my personal finance app.
-I received new transactions that contain unknown merchants/partners.
-
-CURRENT CONFIG:
----
-{{.CurrentConfigYAML}}
----
+`
 
-NEW UNCATEGORIZED MERCHANTS:
-{{- if .OwnerNames}}
-{{.OwnerNamesIndex}}. [OWNER_NAME] ({{len .OwnerNames}} occurrences) - Account owner's transfers, suggested: Miscellaneous / Other Income or Other Expense
-{{- end}}
-{{- if .TransferPartners}}
-{{.TransferPartnersIndex}}. [TRANSFER_PARTNER] ({{len .TransferPartners}} occurrences) - Personal transfers detected, suggested: Miscellaneous / Other Income or Other Expense
-{{- end}}
-{{- if .AccountNumbers}}
-{{.AccountNumbersIndex}}. [ACCOUNT_NUMBER] ({{len .AccountNumbers}} occurrences) - Bank account transfers, suggested: General Transfer / Bank Transfer
-{{- end}}
-{{- range $i, $merchant := .Businesses}}
-{{add $i $.BusinessStartIndex}}. "{{$merchant}}"
-{{- end}}
-
-IMPORTANT INSTRUCTIONS:
-1. RESEARCH each merchant carefully:
-   - Use internet search to identify the business type
-   - Look for keywords in the merchant name (e.g., "Pekseg" = bakery, "Patika" = pharmacy)
-   - Many names contain the business category directly (e.g., "ABC" = grocery, "Kft" = company)
-   - Hungarian business names often include type hints (pékség, patika, étterem, benzinkút, etc.)
-
-2. CATEGORIZATION RULES:
-   - Assign appropriate categories based on merchant business type
-   - For [OWNER_NAME], [TRANSFER_PARTNER], and [ACCOUNT_NUMBER], use the suggested categories above
-   - Add keywords to existing categories that match the business type
-   - Only create new category if absolutely necessary (prefer existing ones)
-
-3. YAML FORMATTING - CRITICAL:
-   - Each keyword MUST be on a separate line with a dash (-)
-   - DO NOT use nested lists like ["item1", "item2"] 
-   - DO NOT use inline arrays
-   - Each category MUST have a 'subcategory' field
-   
-   CORRECT format:
-     keywords:
-       - keyword1
-       - keyword2
-       - keyword3
-   
-   WRONG format (DO NOT DO THIS):
-     keywords:
-       - ["keyword1", "keyword2"]
-
-4. OUTPUT FORMAT:
-   - Your ENTIRE response must be valid YAML (no explanations before/after)
-   - Start your response with: known_partners:
-   - Put the YAML in a code block using triple backticks:
-     ` + "```yaml" + `
-     known_partners:
-       - .....
-     ` + "```" + `
-   - This allows easy copy-paste or download
-
-5. COMPLETENESS:
-   - Include ALL existing categories from CURRENT CONFIG above
-   - Add new merchants to known_partners list (use placeholders like [TRANSFER_PARTNER])
-   - Add new keywords to appropriate categories (one per line!)
-
-AVAILABLE CATEGORY NAMES (from ezBookkeeping defaults):
-{{.AvailableCategories}}
----
+const promptFooter = `---
 
 === END OF PROMPT ===
+`
 
-Found {{.TotalMerchants}} new merchants ({{.AnonymizedCount}} anonymized for privacy).
+const nextStepsTemplate = `
+Found %d new merchants (%d anonymized for privacy).
 
 📋 Next steps:
-1. Copy the prompt above (everything between the --- lines)
+1. Copy the prompt %s
 2. Paste into ChatGPT or Gemini
-3. The LLM will return YAML in a code block - click the copy button on the code block
-4. VERIFY the YAML format - check that keywords are NOT in nested arrays
-5. Save the copied YAML to categories.yaml
+3. Save the LLM's whole response to a file, e.g. response.txt
+4. Merge it into your config: ezbook-convert update-config --apply response.txt --config categories.yaml
+5. Review the printed changes
 6. Run the convert command with the updated config
 `
 
+const nextStepsPartsTemplate = `
+Found %d new merchants (%d anonymized for privacy), split into %d prompts of at most ~%d tokens.
+
+📋 Next steps:
+1. Paste each prompt into ChatGPT or Gemini
+2. Save each response to its own file, e.g. response-1.txt, response-2.txt, ...
+3. Merge them together: ezbook-convert update-config --apply response-1.txt --apply response-2.txt ... --config categories.yaml
+4. Review the printed changes
+5. Run the convert command with the updated config
+`
+
+// UpdateConfigOptions holds the update-config flags
+type UpdateConfigOptions struct {
+	InputPath   string
+	ConfigPath  string
+	MappingPath string
+	Owners      []string // Account owner names in addition to the config's owners section
+	Template    string   // Built-in template name ("en", "hu") or template file path
+	PromptOut   string   // Write the prompt (or JSON) to this file instead of stdout
+	Format      string   // "prompt" or "json"
+	MaxTokens   int      // Split the prompt into parts of about this many tokens, 0 disables splitting
+	LLMEndpoint string   // OpenAI-compatible API base URL; if set the prompt is sent there and the answer merged
+	LLMModel    string
+	LLMAPIKey   string
+}
+
 // UpdateConfigCmd executes the update-config command
-func UpdateConfigCmd(inputPath, configPath string) error {
+// Numbered placeholders for personal data are stored in the mapping file so --apply can translate them back
+// Owner names come from --owner and the config's owners section; they are only asked for interactively on a terminal
+// Status messages go to stderr when the JSON listing is written to stdout
+func UpdateConfigCmd(opts UpdateConfigOptions) error {
+	if opts.Format != "prompt" && opts.Format != "json" {
+		return fmt.Errorf("unknown format %q (use prompt or json)", opts.Format)
+	}
+	if opts.LLMEndpoint != "" && opts.LLMModel == "" {
+		return fmt.Errorf("--model is required with --llm-endpoint")
+	}
+
+	var status io.Writer = os.Stdout
+	if opts.Format == "json" && opts.PromptOut == "" {
+		status = os.Stderr
+	}
+
+	// Parse the template up front so a broken template fails before any prompting
+	tmpl, err := loadPromptTemplate(opts.Template)
+	if err != nil {
+		return err
+	}
+
 	// Load existing config
-	cfg, err := loadConfigOrDefault(configPath)
+	cfg, err := loadConfigOrDefault(opts.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Parse K&H export
-	inputFile, err := os.Open(inputPath)
+	inputFile, err := os.Open(opts.InputPath)
 	if err != nil {
 		return fmt.Errorf("failed to open input file: %w", err)
 	}
 	defer inputFile.Close()
 
-	khTransactions, err := parser.ParseKHExport(inputFile)
+	khTransactions, rowErrors, err := parser.ParseKHExport(inputFile, opts.InputPath)
 	if err != nil {
 		return fmt.Errorf("failed to parse K&H export: %w", err)
 	}
+	reportRowErrors(rowErrors)
 
-	// Extract all partner names and transaction types
+	// Extract all partner names
 	var partnerNames []string
-	var transactionTypes []string
 	for _, t := range khTransactions {
 		if t.PartnerName != "" {
 			partnerNames = append(partnerNames, t.PartnerName)
-			transactionTypes = append(transactionTypes, t.Type)
 		}
 	}
 
@@ -135,43 +121,61 @@ func UpdateConfigCmd(inputPath, configPath string) error {
 	uncategorized := cat.GetUncategorizedPartners(partnerNames)
 
 	if len(uncategorized) == 0 {
-		fmt.Println("✓ All merchants are already in the known_partners list!")
-		fmt.Println("No new categorization needed.")
+		fmt.Fprintln(status, "✓ All merchants are already in the known_partners list!")
+		fmt.Fprintln(status, "No new categorization needed.")
 		return nil
 	}
 
-	// Ask for account owner name for privacy protection
-	fmt.Println("\n🔒 Privacy Protection Setup")
-	fmt.Println("To protect personal data, we need to know the account owner's name.")
-	fmt.Print("Enter account owner name (e.g., 'Vigh Dániel') or press Enter to skip: ")
-	
-	var ownerName string
-	fmt.Scanln(&ownerName)
-	ownerName = strings.TrimSpace(ownerName)
+	// Account owner names for privacy protection
+	ownerNames := resolveOwners(status, opts.Owners, cfg)
+	if len(ownerNames) == 0 {
+		fmt.Fprintln(status, "\n⚠️  No account owner configured, the owner's name will not be anonymized")
+		fmt.Fprintln(status, "   Use --owner or add an owners: section to the config")
+	}
+
+	mapping, err := anonymizer.LoadMapping(opts.MappingPath)
+	if err != nil {
+		return fmt.Errorf("failed to load placeholder mapping: %w", err)
+	}
 
 	// Prepare anonymization config
 	anonCfg := &anonymizer.Config{
-		OwnerName: ownerName,
+		OwnerNames: ownerNames,
+		Mapping:    mapping,
 	}
 
-	// Build map of partner to transaction type for anonymization
-	partnerTypeMap := make(map[string]string)
-	for i, partner := range partnerNames {
-		if i < len(transactionTypes) {
-			partnerTypeMap[partner] = transactionTypes[i]
+	// Collect occurrence counts, amounts and K&H types per partner
+	stats := collectMerchantStats(khTransactions, cat)
+
+	// Keep the first note per partner as context for the LLM
+	partnerNoteMap := make(map[string]string)
+	for _, t := range khTransactions {
+		merchantName := cat.Canonicalize(t.PartnerName)
+		if _, ok := partnerNoteMap[merchantName]; !ok && t.Description != "" && t.Description != t.PartnerName {
+			partnerNoteMap[merchantName] = t.Description
 		}
 	}
 
 	// Anonymize merchant names (protect personal data)
 	var anonymized []anonymizer.AnonymizationResult
 	for _, partner := range uncategorized {
-		transType := partnerTypeMap[partner]
-		result := anonymizer.Anonymize(partner, transType, anonCfg)
+		result := anonymizer.Anonymize(partner, stats[partner].mainType(), anonCfg)
 		anonymized = append(anonymized, result)
 	}
 
+	// Anonymize notes (account numbers, card numbers, owner name in free text)
+	notes := make(map[string]string)
+	var noteFindings []string
+	for _, partner := range uncategorized {
+		note, findings := anonymizer.AnonymizeText(partnerNoteMap[partner], anonCfg)
+		notes[partner] = note
+		for _, f := range findings {
+			noteFindings = append(noteFindings, fmt.Sprintf("\"%s\" → %s (%s in note)", f.Value, f.Placeholder, f.Kind))
+		}
+	}
+
 	// Show user what will be anonymized
-	personalDataCount := 0
+	personalDataCount := len(noteFindings)
 	for _, result := range anonymized {
 		if result.IsPersonal {
 			personalDataCount++
@@ -179,122 +183,179 @@ func UpdateConfigCmd(inputPath, configPath string) error {
 	}
 
 	if personalDataCount > 0 {
-		fmt.Printf("\n🔒 %d personal data item(s) detected and will be anonymized:\n", personalDataCount)
+		fmt.Fprintf(status, "\n🔒 %d personal data item(s) detected and will be anonymized:\n", personalDataCount)
 		for _, result := range anonymized {
 			if result.IsPersonal {
-				fmt.Printf("  • \"%s\" → %s (%s)\n", result.Original, result.Anonymized, result.DetectionType)
+				fmt.Fprintf(status, "  • \"%s\" → %s (%s)\n", result.Original, result.Anonymized, result.DetectionType)
 			}
 		}
-		fmt.Println()
+		for _, finding := range noteFindings {
+			fmt.Fprintf(status, "  • %s\n", finding)
+		}
+		fmt.Fprintln(status)
+	}
+
+	// Personal data in the config's known partners and exact matches gets placeholders too
+	data, err := buildPromptData(cfg, anonCfg, anonymized, notes, stats)
+	if err != nil {
+		return err
+	}
+
+	// Save before anything is rendered, so every placeholder in a prompt can be translated back
+	if len(mapping.Placeholders) > 0 {
+		if err := anonymizer.SaveMapping(opts.MappingPath, mapping); err != nil {
+			return fmt.Errorf("failed to save placeholder mapping: %w", err)
+		}
+		fmt.Fprintf(status, "Placeholder mapping saved to: %s (keep this file private)\n\n", opts.MappingPath)
 	}
 
-	// Generate LLM prompt
-	generateLLMPrompt(cfg, anonymized)
+	if opts.Format == "json" {
+		return writeJSON(opts, status, data)
+	}
 
+	// Split large backlogs into several prompts that each ask for a YAML delta
+	parts, err := splitPrompt(data, tmpl, opts.MaxTokens)
+	if err != nil {
+		return err
+	}
+
+	if opts.LLMEndpoint != "" {
+		return queryLLM(opts, tmpl, parts, cfg, mapping)
+	}
+
+	return writePrompts(opts, status, tmpl, parts)
+}
+
+// writeJSON writes the unknown merchants to stdout or --prompt-out
+func writeJSON(opts UpdateConfigOptions, status io.Writer, data *PromptData) error {
+	var rendered bytes.Buffer
+	if err := writeMerchantsJSON(&rendered, data); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+
+	if opts.PromptOut == "" {
+		os.Stdout.Write(rendered.Bytes())
+		return nil
+	}
+
+	if err := os.WriteFile(opts.PromptOut, rendered.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+	fmt.Fprintf(status, "✓ %d unknown merchants written to: %s\n", data.TotalMerchants, opts.PromptOut)
 	return nil
 }
 
-func generateLLMPrompt(cfg *config.Config, anonymized []anonymizer.AnonymizationResult) {
-	// Group by anonymization type
-	ownerNames := []string{}
-	transferPartners := []string{}
-	accountNumbers := []string{}
-	businesses := []string{}
-	
-	for _, result := range anonymized {
-		switch result.DetectionType {
-		case "owner_name":
-			ownerNames = append(ownerNames, result.Anonymized)
-		case "transfer_partner":
-			transferPartners = append(transferPartners, result.Anonymized)
-		case "account_number":
-			accountNumbers = append(accountNumbers, result.Anonymized)
-		default:
-			businesses = append(businesses, result.Anonymized)
+// writePrompts renders each prompt part to stdout or to --prompt-out (numbered files for several parts)
+func writePrompts(opts UpdateConfigOptions, status io.Writer, tmpl *template.Template, parts []*PromptData) error {
+	// Render first so a failing template doesn't leave a half-written prompt
+	rendered, err := renderPrompts(tmpl, parts)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	anonymized := 0
+	for _, part := range parts {
+		total += part.TotalMerchants
+		anonymized += part.AnonymizedCount
+	}
+
+	if opts.PromptOut == "" {
+		// Stdout gets copy markers around each prompt
+		for i, prompt := range rendered {
+			label := ""
+			if len(parts) > 1 {
+				label = fmt.Sprintf(" %d/%d", i+1, len(parts))
+			}
+			fmt.Printf(promptHeader, label)
+			os.Stdout.Write(prompt)
+			fmt.Print(promptFooter)
+		}
+	} else {
+		for i, prompt := range rendered {
+			path := opts.PromptOut
+			if len(parts) > 1 {
+				path = numberedPath(opts.PromptOut, i+1)
+			}
+			if err := os.WriteFile(path, prompt, 0644); err != nil {
+				return fmt.Errorf("failed to write prompt file: %w", err)
+			}
+			fmt.Fprintf(status, "✓ Prompt written to: %s\n", path)
 		}
 	}
-	
-	// Calculate indices for prompt
-	idx := 1
-	ownerNamesIndex := 0
-	transferPartnersIndex := 0
-	accountNumbersIndex := 0
-	businessStartIndex := 1
-	
-	if len(ownerNames) > 0 {
-		ownerNamesIndex = idx
-		idx++
-	}
-	if len(transferPartners) > 0 {
-		transferPartnersIndex = idx
-		idx++
-	}
-	if len(accountNumbers) > 0 {
-		accountNumbersIndex = idx
-		idx++
-	}
-	businessStartIndex = idx
-
-	// Serialize current config to YAML
-	yamlData, err := yaml.Marshal(cfg)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error serializing config: %v\n", err)
-		return
-	}
-
-	// Prepare template data
-	data := struct {
-		CurrentConfigYAML     string
-		OwnerNames            []string
-		TransferPartners      []string
-		AccountNumbers        []string
-		Businesses            []string
-		OwnerNamesIndex       int
-		TransferPartnersIndex int
-		AccountNumbersIndex   int
-		BusinessStartIndex    int
-		TotalMerchants        int
-		AnonymizedCount       int
-		AvailableCategories   string
-	}{
-		CurrentConfigYAML:     string(yamlData),
-		OwnerNames:            ownerNames,
-		TransferPartners:      transferPartners,
-		AccountNumbers:        accountNumbers,
-		Businesses:            businesses,
-		OwnerNamesIndex:       ownerNamesIndex,
-		TransferPartnersIndex: transferPartnersIndex,
-		AccountNumbersIndex:   accountNumbersIndex,
-		BusinessStartIndex:    businessStartIndex,
-		TotalMerchants:        len(anonymized),
-		AnonymizedCount:       len(ownerNames) + len(transferPartners) + len(accountNumbers),
-		AvailableCategories:   getAvailableCategories(),
-	}
-
-	// Create template with helper functions
-	tmpl := template.Must(template.New("prompt").Funcs(template.FuncMap{
-		"add": func(a, b int) int { return a + b + 1 },
-	}).Parse(llmPromptTemplate))
-
-	// Execute template
-	if err := tmpl.Execute(os.Stdout, data); err != nil {
-		fmt.Fprintf(os.Stderr, "Error executing template: %v\n", err)
+
+	if len(parts) > 1 {
+		fmt.Fprintf(status, nextStepsPartsTemplate, total, anonymized, len(parts), opts.MaxTokens)
+		return nil
+	}
+
+	where := "above (everything between the --- lines)"
+	if opts.PromptOut != "" {
+		where = "from " + opts.PromptOut
 	}
+	fmt.Fprintf(status, nextStepsTemplate, total, anonymized, where)
+	return nil
+}
+
+// renderPrompts executes the template for every prompt part
+func renderPrompts(tmpl *template.Template, parts []*PromptData) ([][]byte, error) {
+	rendered := make([][]byte, len(parts))
+	for i, part := range parts {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, part); err != nil {
+			return nil, fmt.Errorf("failed to execute template: %w", err)
+		}
+		rendered[i] = b.Bytes()
+	}
+	return rendered, nil
+}
+
+// numberedPath inserts a part number before the extension: prompt.txt → prompt-2.txt
+func numberedPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// resolveOwners combines --owner flags with the config's owners section
+// If neither is set and stdin is a terminal, the user is asked; several owners can be given comma-separated
+func resolveOwners(status io.Writer, flagOwners []string, cfg *config.Config) []string {
+	var owners []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			owners = append(owners, name)
+		}
+	}
+
+	for _, name := range flagOwners {
+		add(name)
+	}
+	for _, name := range cfg.Owners {
+		add(name)
+	}
+
+	if len(owners) > 0 || !isTerminal(os.Stdin) {
+		return owners
+	}
+
+	fmt.Fprintln(status, "\n🔒 Privacy Protection Setup")
+	fmt.Fprintln(status, "To protect personal data, we need to know the account owner's name.")
+	fmt.Fprint(status, "Enter account owner name(s), comma-separated (e.g., 'Vigh Dániel') or press Enter to skip: ")
+
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	for _, name := range strings.Split(line, ",") {
+		add(name)
+	}
+	return owners
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a pipe, file or /dev/null
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
 }
 
 func getAvailableCategories() string {
-	categories := []string{
-		"Food & Drink",
-		"Clothing & Appearance",
-		"Housing & Houseware",
-		"Transportation",
-		"Communication",
-		"Entertainment",
-		"Education & Studying",
-		"Medical & Healthcare",
-		"Gift & Social",
-		"Finance & Insurance",
-		"Miscellaneous",
-	}
-	return strings.Join(categories, ", ")
+	return strings.Join(config.DefaultExpenseCategories, ", ")
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"ezbook-convert/internal/config"
+)
+
+func TestIsTerminal(t *testing.T) {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	// /dev/null is a character device, but not a terminal
+	if isTerminal(devNull) {
+		t.Errorf("isTerminal(%s) = true", os.DevNull)
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+	if isTerminal(reader) {
+		t.Error("isTerminal(pipe) = true")
+	}
+}
+
+func TestResolveOwners(t *testing.T) {
+	cfg := config.New()
+	cfg.Owners = []string{"Kovács Béla", "Nagy Anna"}
+
+	got := resolveOwners(io.Discard, []string{" kovács béla ", "Kiss Péter", ""}, cfg)
+	if want := []string{"kovács béla", "Kiss Péter", "Nagy Anna"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveOwners = %q, want %q", got, want)
+	}
+
+	// Without a terminal nobody is asked
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdin = devNull
+
+	if got := resolveOwners(io.Discard, nil, config.New()); len(got) != 0 {
+		t.Errorf("resolveOwners without owners = %q, want none", got)
+	}
+}
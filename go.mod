@@ -2,4 +2,9 @@ module ezbook-convert
 
 go 1.25.4
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	golang.org/x/term v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.41.0 // indirect
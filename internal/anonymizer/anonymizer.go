@@ -10,7 +10,7 @@ type AnonymizationResult struct {
 	Original      string
 	Anonymized    string
 	IsPersonal    bool
-	DetectionType string // "owner_name", "transfer_partner", "account_number", "none"
+	DetectionType string // "owner_name", "transfer_partner", "personal_name", "account_number", "card_number", "tax_number", "company_registration", "none"
 }
 
 var (
@@ -20,7 +20,8 @@ var (
 
 // Config for anonymization
 type Config struct {
-	OwnerName string // Account owner name to anonymize
+	OwnerNames []string // Account owner names to anonymize, several for joint accounts
+	Mapping    *Mapping // Assigns numbered placeholders ([PERSON_1]) if set, otherwise generic ones are used
 }
 
 // Anonymize determines if a merchant name should be anonymized
@@ -40,20 +41,32 @@ func Anonymize(merchantName, transactionType string, cfg *Config) AnonymizationR
 	if accountNumberPattern.MatchString(trimmed) {
 		return AnonymizationResult{
 			Original:      merchantName,
-			Anonymized:    "[ACCOUNT_NUMBER]",
+			Anonymized:    placeholder(cfg, "ACCOUNT", trimmed, "[ACCOUNT_NUMBER]"),
 			IsPersonal:    true,
 			DetectionType: "account_number",
 		}
 	}
 
-	// Check if it's the account owner's name
-	if cfg != nil && cfg.OwnerName != "" {
-		if strings.EqualFold(trimmed, cfg.OwnerName) {
-			return AnonymizationResult{
-				Original:      merchantName,
-				Anonymized:    "[OWNER_NAME]",
-				IsPersonal:    true,
-				DetectionType: "owner_name",
+	// Check for account, card, tax and company registration numbers inside the name
+	if anonymized, findings := AnonymizeText(trimmed, identifiersOnly(cfg)); len(findings) > 0 {
+		return AnonymizationResult{
+			Original:      merchantName,
+			Anonymized:    anonymized,
+			IsPersonal:    true,
+			DetectionType: findings[0].Kind,
+		}
+	}
+
+	// Check if it's the account owner's name (accent-insensitive, either name order)
+	if cfg != nil {
+		for _, ownerName := range cfg.OwnerNames {
+			if isOwnerName(trimmed, ownerName) {
+				return AnonymizationResult{
+					Original:      merchantName,
+					Anonymized:    placeholder(cfg, "OWNER", trimmed, "[OWNER_NAME]"),
+					IsPersonal:    true,
+					DetectionType: "owner_name",
+				}
 			}
 		}
 	}
@@ -68,12 +81,22 @@ func Anonymize(merchantName, transactionType string, cfg *Config) AnonymizationR
 		// In transfers, the partner name is likely a person
 		return AnonymizationResult{
 			Original:      merchantName,
-			Anonymized:    "[TRANSFER_PARTNER]",
+			Anonymized:    placeholder(cfg, "PERSON", trimmed, "[TRANSFER_PARTNER]"),
 			IsPersonal:    true,
 			DetectionType: "transfer_partner",
 		}
 	}
 
+	// Check for private persons outside transfers, e.g. "SIMPLEP baloghekszer"
+	if isPersonName(trimmed) {
+		return AnonymizationResult{
+			Original:      merchantName,
+			Anonymized:    placeholder(cfg, "PERSON", trimmed, "[PERSON]"),
+			IsPersonal:    true,
+			DetectionType: "personal_name",
+		}
+	}
+
 	// Default: not personal data (business/merchant names)
 	return AnonymizationResult{
 		Original:      merchantName,
@@ -83,6 +106,34 @@ func Anonymize(merchantName, transactionType string, cfg *Config) AnonymizationR
 	}
 }
 
+// AnonymizeValue anonymizes a known partner or exact match of the config for the prompt
+// Values that already have a placeholder in the mapping keep it, e.g. a partner written back by --apply;
+// the others are checked like merchant names, without a transaction type
+func AnonymizeValue(value string, cfg *Config) string {
+	if cfg != nil && cfg.Mapping != nil {
+		if placeholder, ok := cfg.Mapping.Lookup(strings.TrimSpace(value)); ok {
+			return placeholder
+		}
+	}
+	return Anonymize(value, "", cfg).Anonymized
+}
+
+// identifiersOnly drops the owner name so AnonymizeText only replaces identifiers
+func identifiersOnly(cfg *Config) *Config {
+	if cfg == nil {
+		return nil
+	}
+	return &Config{Mapping: cfg.Mapping}
+}
+
+// placeholder returns a numbered placeholder from the mapping, or the generic one without mapping
+func placeholder(cfg *Config, kind, original, generic string) string {
+	if cfg == nil || cfg.Mapping == nil {
+		return generic
+	}
+	return cfg.Mapping.Placeholder(kind, original)
+}
+
 // AnonymizeMerchantList anonymizes a list of merchant names with their transaction types
 func AnonymizeMerchantList(merchants []string, transactionTypes []string, cfg *Config) []AnonymizationResult {
 	results := make([]AnonymizationResult, len(merchants))
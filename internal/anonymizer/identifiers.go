@@ -0,0 +1,213 @@
+package anonymizer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Finding is an identifier detected in a partner name or description
+type Finding struct {
+	Kind        string // "account_number", "card_number", "tax_number", "company_registration", "owner_name"
+	Value       string
+	Placeholder string
+}
+
+// identifierRule detects one kind of identifier in free text
+type identifierRule struct {
+	kind        string
+	pattern     *regexp.Regexp
+	valid       func(value string) bool
+	mappingKind string // Placeholder kind for numbered placeholders
+	generic     string // Placeholder without mapping
+}
+
+// Rules are tried in order; earlier rules win when matches overlap
+var identifierRules = []identifierRule{
+	// IBAN, e.g. HU42 1177 3016 1111 1018 0000 0000
+	{"account_number", regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`), validIBAN, "ACCOUNT", "[ACCOUNT_NUMBER]"},
+	// Hungarian BBAN, 8-8 or 8-8-8 digits, e.g. 10400000-12345678-00000000
+	{"account_number", regexp.MustCompile(`\b\d{8}[- ]\d{8}(?:[- ]\d{8})?\b`), validBBAN, "ACCOUNT", "[ACCOUNT_NUMBER]"},
+	// Hungarian tax number (adószám), e.g. 12345676-2-41
+	{"tax_number", regexp.MustCompile(`\b\d{8}-[1-5]-\d{2}\b`), validTaxNumber, "TAX_NUMBER", "[TAX_NUMBER]"},
+	// Hungarian personal tax ID (adóazonosító jel), 10 digits starting with 8
+	{"tax_number", regexp.MustCompile(`\b8\d{9}\b`), validTaxID, "TAX_NUMBER", "[TAX_NUMBER]"},
+	// Company registration number (cégjegyzékszám), e.g. 01-09-123456
+	{"company_registration", regexp.MustCompile(`\b(?:0[1-9]|1\d|20)-(?:0[1-9]|1\d|2[0-3])-\d{6}\b`), nil, "COMPANY_NUMBER", "[COMPANY_NUMBER]"},
+	// Masked card numbers, e.g. 123456******1234 or **** **** **** 1234
+	{"card_number", regexp.MustCompile(`\b\d{4,6}[*Xx]{4,9}\d{4}\b|(?:[*Xx]{4}[ -]?){2,3}\d{4}\b|\b\d{4}[ -](?:[*Xx]{4}[ -]){2}\d{4}\b`), nil, "CARD", "[CARD_NUMBER]"},
+	// Card numbers (PAN), 13-19 digits with optional separators, Luhn checked
+	{"card_number", regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), validLuhn, "CARD", "[CARD_NUMBER]"},
+	// Unformatted Hungarian BBAN, 16 or 24 digits
+	{"account_number", regexp.MustCompile(`\b\d{16}(?:\d{8})?\b`), validBBAN, "ACCOUNT", "[ACCOUNT_NUMBER]"},
+}
+
+// AnonymizeText replaces account, card, tax and company registration numbers and
+// the owners' names in free text (e.g. a transaction description) with placeholders
+func AnonymizeText(text string, cfg *Config) (string, []Finding) {
+	type span struct {
+		start, end int
+		finding    Finding
+	}
+	var spans []span
+
+	overlaps := func(start, end int) bool {
+		for _, s := range spans {
+			if start < s.end && end > s.start {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rule := range identifierRules {
+		for _, loc := range rule.pattern.FindAllStringIndex(text, -1) {
+			value := text[loc[0]:loc[1]]
+			if overlaps(loc[0], loc[1]) {
+				continue
+			}
+			if rule.valid != nil && !rule.valid(value) {
+				continue
+			}
+			spans = append(spans, span{loc[0], loc[1], Finding{
+				Kind:        rule.kind,
+				Value:       value,
+				Placeholder: placeholder(cfg, rule.mappingKind, value, rule.generic),
+			}})
+		}
+	}
+
+	if cfg != nil {
+		for _, ownerName := range cfg.OwnerNames {
+			ownerPattern := ownerNamePattern(ownerName)
+			if ownerPattern == nil {
+				continue
+			}
+			for _, loc := range ownerPattern.FindAllStringSubmatchIndex(text, -1) {
+				start, end := loc[2], loc[3]
+				if overlaps(start, end) {
+					continue
+				}
+				spans = append(spans, span{start, end, Finding{
+					Kind:        "owner_name",
+					Value:       text[start:end],
+					Placeholder: placeholder(cfg, "OWNER", text[start:end], "[OWNER_NAME]"),
+				}})
+			}
+		}
+	}
+
+	if len(spans) == 0 {
+		return text, nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	var findings []Finding
+	last := 0
+	for _, s := range spans {
+		b.WriteString(text[last:s.start])
+		b.WriteString(s.finding.Placeholder)
+		last = s.end
+		findings = append(findings, s.finding)
+	}
+	b.WriteString(text[last:])
+
+	return b.String(), findings
+}
+
+// validBBAN checks the Hungarian account number check digits
+// Each 8 or 16 digit block weighted with 9,7,3,1 repeating must sum to a multiple of 10
+func validBBAN(value string) bool {
+	digits := digitsOnly(value)
+	if len(digits) != 16 && len(digits) != 24 {
+		return false
+	}
+	if strings.Trim(digits, "0") == "" {
+		return false
+	}
+	return weightedBlockValid(digits[:8]) && weightedBlockValid(digits[8:])
+}
+
+// validTaxNumber checks the company tax number (adószám) check digit in the first 8 digits
+func validTaxNumber(value string) bool {
+	digits := digitsOnly(value)
+	return len(digits) == 11 && weightedBlockValid(digits[:8])
+}
+
+// validTaxID checks the personal tax ID (adóazonosító jel) check digit
+func validTaxID(value string) bool {
+	digits := digitsOnly(value)
+	if len(digits) != 10 || digits[0] != '8' {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(digits[i]-'0') * (i + 1)
+	}
+	return sum%11 == int(digits[9]-'0')
+}
+
+func weightedBlockValid(block string) bool {
+	weights := []int{9, 7, 3, 1}
+	sum := 0
+	for i, r := range block {
+		sum += int(r-'0') * weights[i%4]
+	}
+	return sum%10 == 0
+}
+
+// validLuhn checks a card number checksum
+func validLuhn(value string) bool {
+	digits := digitsOnly(value)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validIBAN checks the ISO 13616 mod-97 checksum
+func validIBAN(value string) bool {
+	iban := strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+
+	remainder := 0
+	for _, r := range rearranged {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A'+10)) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+func digitsOnly(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
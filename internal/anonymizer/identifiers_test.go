@@ -0,0 +1,96 @@
+package anonymizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid func(string) bool
+		value string
+		want  bool
+	}{
+		{"IBAN", validIBAN, "HU42117730161111101800000000", true},
+		{"IBAN with spaces", validIBAN, "HU42 1177 3016 1111 1018 0000 0000", true},
+		{"IBAN lowercase", validIBAN, "hu42117730161111101800000000", true},
+		{"IBAN other country", validIBAN, "DE89370400440532013000", true},
+		{"IBAN wrong check digits", validIBAN, "HU43117730161111101800000000", false},
+		{"IBAN too short", validIBAN, "HU4211773016", false},
+		{"IBAN invalid character", validIBAN, "HU42-1177-3016-1111-1018", false},
+
+		{"BBAN 2x8", validBBAN, "11773016-11111018", true},
+		{"BBAN 3x8", validBBAN, "11773016-11111018-00000000", true},
+		{"BBAN unformatted", validBBAN, "1177301611111018", true},
+		{"BBAN wrong bank check digit", validBBAN, "11773017-11111018", false},
+		{"BBAN wrong account check digit", validBBAN, "11773016-11111019", false},
+		{"BBAN all zeros", validBBAN, "00000000-00000000", false},
+		{"BBAN wrong length", validBBAN, "11773016-1111101", false},
+
+		{"Luhn", validLuhn, "4111111111111111", true},
+		{"Luhn with separators", validLuhn, "4111 1111 1111 1111", true},
+		{"Luhn 19 digits wrong checksum", validLuhn, "6011000990139424000", false},
+		{"Luhn wrong checksum", validLuhn, "4111111111111112", false},
+		{"Luhn too short", validLuhn, "411111111111", false},
+
+		{"tax number", validTaxNumber, "12345676-2-41", true},
+		{"tax number wrong check digit", validTaxNumber, "12345677-2-41", false},
+		{"tax ID", validTaxID, "8123456786", true},
+		{"tax ID wrong check digit", validTaxID, "8123456787", false},
+		{"tax ID not starting with 8", validTaxID, "7123456786", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.valid(tt.value); got != tt.want {
+			t.Errorf("%s: valid(%q) = %v, want %v", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAnonymizeText(t *testing.T) {
+	cfg := &Config{OwnerNames: []string{"Kovács Béla"}, Mapping: NewMapping()}
+
+	tests := []struct {
+		text  string
+		want  string
+		kinds []string
+	}{
+		{
+			"Átutalás HU42 1177 3016 1111 1018 0000 0000 számlára",
+			"Átutalás [ACCOUNT_1] számlára",
+			[]string{"account_number"},
+		},
+		{
+			"Kártya: 411111******1111, adószám 12345676-2-41",
+			"Kártya: [CARD_1], adószám [TAX_NUMBER_1]",
+			[]string{"card_number", "tax_number"},
+		},
+		{
+			"Cg. 01-09-123456, számla 11773016-11111018",
+			"Cg. [COMPANY_NUMBER_1], számla [ACCOUNT_2]",
+			[]string{"company_registration", "account_number"},
+		},
+		{
+			"KOVACS BELA részére 4111 1111 1111 1111",
+			"[OWNER_1] részére [CARD_2]",
+			[]string{"owner_name", "card_number"},
+		},
+		// Check digits keep order numbers and amounts from being replaced
+		{"Rendelés 12345678-12345678, összeg 4111111111111112", "Rendelés 12345678-12345678, összeg 4111111111111112", nil},
+	}
+
+	for _, tt := range tests {
+		got, findings := AnonymizeText(tt.text, cfg)
+		if got != tt.want {
+			t.Errorf("AnonymizeText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		var kinds []string
+		for _, f := range findings {
+			kinds = append(kinds, f.Kind)
+		}
+		if !reflect.DeepEqual(kinds, tt.kinds) {
+			t.Errorf("AnonymizeText(%q) kinds = %q, want %q", tt.text, kinds, tt.kinds)
+		}
+	}
+}
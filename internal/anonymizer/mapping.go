@@ -0,0 +1,128 @@
+package anonymizer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Numbered placeholders like [PERSON_1] or [TAX_NUMBER_2]
+var placeholderPattern = regexp.MustCompile(`\[([A-Z_]+)_(\d+)\]`)
+
+// Placeholders that stand for personal data, the generic owner placeholder included
+var personalPlaceholderPattern = regexp.MustCompile(`\[(?:[A-Z_]+_\d+|OWNER_NAME)\]`)
+
+// Mapping assigns stable numbered placeholders to personal data
+// It is stored locally so placeholders in LLM responses can be translated back to real names
+type Mapping struct {
+	Placeholders map[string]string `yaml:"placeholders"` // Placeholder → original value
+
+	originals map[string]string // Original value → placeholder
+	next      map[string]int    // Kind → next free number
+}
+
+// NewMapping creates an empty mapping
+func NewMapping() *Mapping {
+	m := &Mapping{Placeholders: make(map[string]string)}
+	m.index()
+	return m
+}
+
+// LoadMapping reads a mapping file, returning an empty mapping if it does not exist yet
+func LoadMapping(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewMapping(), nil
+		}
+		return nil, err
+	}
+
+	var m Mapping
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid mapping file: %w", err)
+	}
+	if m.Placeholders == nil {
+		m.Placeholders = make(map[string]string)
+	}
+	m.index()
+
+	return &m, nil
+}
+
+// SaveMapping writes the mapping file, readable only by the current user
+func SaveMapping(path string, m *Mapping) error {
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	header := []byte("# Placeholder mapping for anonymized LLM prompts - contains personal data, do not share\n")
+	return os.WriteFile(path, append(header, data...), 0600)
+}
+
+// Placeholder returns the placeholder for a value, assigning the next number of its kind if new
+func (m *Mapping) Placeholder(kind, original string) string {
+	if placeholder, ok := m.originals[original]; ok {
+		return placeholder
+	}
+
+	m.next[kind]++
+	placeholder := fmt.Sprintf("[%s_%d]", kind, m.next[kind])
+	m.Placeholders[placeholder] = original
+	m.originals[original] = placeholder
+	return placeholder
+}
+
+// Lookup returns the placeholder already assigned to an original value, without assigning a new one
+func (m *Mapping) Lookup(original string) (string, bool) {
+	placeholder, ok := m.originals[original]
+	return placeholder, ok
+}
+
+// Resolve returns the original value of a placeholder
+func (m *Mapping) Resolve(placeholder string) (string, bool) {
+	original, ok := m.Placeholders[placeholder]
+	return original, ok
+}
+
+// ResolveText replaces every known placeholder inside a value with its original
+// Returns the number of placeholders replaced
+func (m *Mapping) ResolveText(value string) (string, int) {
+	count := 0
+	resolved := placeholderPattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+		if original, ok := m.Placeholders[placeholder]; ok {
+			count++
+			return original
+		}
+		return placeholder
+	})
+	return resolved, count
+}
+
+// index rebuilds the reverse lookup and counters after loading
+func (m *Mapping) index() {
+	m.originals = make(map[string]string)
+	m.next = make(map[string]int)
+
+	for placeholder, original := range m.Placeholders {
+		m.originals[original] = placeholder
+
+		match := placeholderPattern.FindStringSubmatch(placeholder)
+		if match == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(match[2]); err == nil && n > m.next[match[1]] {
+			m.next[match[1]] = n
+		}
+	}
+}
+
+// FindPlaceholders returns the numbered and owner placeholders in a value
+// After ResolveText these are the ones the mapping doesn't know
+func FindPlaceholders(value string) []string {
+	return personalPlaceholderPattern.FindAllString(value, -1)
+}
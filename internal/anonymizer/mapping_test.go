@@ -0,0 +1,110 @@
+package anonymizer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMappingPlaceholders(t *testing.T) {
+	m := NewMapping()
+
+	tests := []struct {
+		kind, original, want string
+	}{
+		{"PERSON", "Nagy Anna", "[PERSON_1]"},
+		{"PERSON", "Kiss Péter", "[PERSON_2]"},
+		{"ACCOUNT", "11773016-11111018", "[ACCOUNT_1]"},
+		{"PERSON", "Nagy Anna", "[PERSON_1]"},
+	}
+	for _, tt := range tests {
+		if got := m.Placeholder(tt.kind, tt.original); got != tt.want {
+			t.Errorf("Placeholder(%s, %q) = %s, want %s", tt.kind, tt.original, got, tt.want)
+		}
+	}
+
+	if original, ok := m.Resolve("[PERSON_2]"); !ok || original != "Kiss Péter" {
+		t.Errorf("Resolve([PERSON_2]) = %q, %v", original, ok)
+	}
+	if placeholder, ok := m.Lookup("Nagy Anna"); !ok || placeholder != "[PERSON_1]" {
+		t.Errorf("Lookup(Nagy Anna) = %q, %v", placeholder, ok)
+	}
+	if _, ok := m.Lookup("TESCO"); ok {
+		t.Error("Lookup should not assign placeholders")
+	}
+
+	resolved, count := m.ResolveText("Rent to [PERSON_1] from [ACCOUNT_1], not [PERSON_7]")
+	if want := "Rent to Nagy Anna from 11773016-11111018, not [PERSON_7]"; resolved != want || count != 2 {
+		t.Errorf("ResolveText = %q, %d, want %q, 2", resolved, count, want)
+	}
+}
+
+func TestMappingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mapping.yaml")
+
+	m, err := LoadMapping(path)
+	if err != nil {
+		t.Fatalf("LoadMapping of a missing file: %v", err)
+	}
+	m.Placeholder("PERSON", "Nagy Anna")
+	m.Placeholder("PERSON", "Kiss Péter")
+	if err := SaveMapping(path, m); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mapping file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// Numbering continues after the placeholders already in the file
+	loaded, err := LoadMapping(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Placeholder("PERSON", "Kiss Péter"); got != "[PERSON_2]" {
+		t.Errorf("Placeholder(Kiss Péter) = %s, want [PERSON_2]", got)
+	}
+	if got := loaded.Placeholder("PERSON", "Szabó Éva"); got != "[PERSON_3]" {
+		t.Errorf("Placeholder(Szabó Éva) = %s, want [PERSON_3]", got)
+	}
+}
+
+func TestAnonymizeValue(t *testing.T) {
+	m := NewMapping()
+	m.Placeholder("PERSON", "Nagy Anna")
+	cfg := &Config{OwnerNames: []string{"Kovács Béla"}, Mapping: m}
+
+	tests := []struct {
+		value, want string
+	}{
+		{"Nagy Anna", "[PERSON_1]"},
+		{"BELA KOVACS", "[OWNER_1]"},
+		{"Kovács Béla", "[OWNER_2]"},
+		{"TESCO", "TESCO"},
+		// Names written by categorize, learn or by hand are not in the mapping yet
+		{"Kiss Péter", "[PERSON_2]"},
+		{"SIMPLEP kisspeter", "[PERSON_3]"},
+		{"HU42 1177 3016 1111 1018 0000 0000", "[ACCOUNT_1]"},
+		{"BELA KOVACS", "[OWNER_1]"},
+	}
+	for _, tt := range tests {
+		if got := AnonymizeValue(tt.value, cfg); got != tt.want {
+			t.Errorf("AnonymizeValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+	if original, ok := m.Resolve("[OWNER_1]"); !ok || original != "BELA KOVACS" {
+		t.Errorf("Resolve([OWNER_1]) = %q, %v, want the spelling used in the config", original, ok)
+	}
+}
+
+func TestFindPlaceholders(t *testing.T) {
+	got := FindPlaceholders("[OWNER_NAME] paid [PERSON_12] via [TRANSFER_PARTNER] and [ACCOUNT_NUMBER]")
+	if want := []string{"[OWNER_NAME]", "[PERSON_12]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindPlaceholders = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package anonymizer
+
+import (
+	_ "embed"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ezbook-convert/internal/textnorm"
+)
+
+var (
+	//go:embed names/given_names.txt
+	givenNamesData string
+
+	//go:embed names/surnames.txt
+	surnamesData string
+
+	givenNames = loadNameList(givenNamesData)
+	surnames   = loadNameList(surnamesData)
+)
+
+// Payment provider prefixes that precede the real payee, e.g. "SIMPLEP baloghekszer"
+var paymentPrefixes = map[string]bool{
+	"simplep": true,
+	"barionp": true,
+	"revolut": true,
+	"paypal":  true,
+	"wise":    true,
+	"gopay":   true,
+}
+
+// Tokens that mark a business rather than a private person
+var businessMarkers = map[string]bool{
+	"kft": true, "bt": true, "zrt": true, "nyrt": true, "kkt": true, "rt": true,
+	"inc": true, "ltd": true, "llc": true, "gmbh": true, "sz": true,
+	"abc": true, "shop": true, "market": true, "bolt": true, "patika": true,
+	"etterem": true, "pekseg": true, "cafe": true, "bar": true, "hotel": true,
+	"szerviz": true, "service": true, "center": true, "plaza": true, "store": true,
+}
+
+// loadNameList parses an embedded name list, skipping comments and blank lines
+func loadNameList(data string) map[string]bool {
+	names := make(map[string]bool)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names[textnorm.Fold(line)] = true
+	}
+	return names
+}
+
+// isPersonName reports whether a partner name looks like a private person
+// Accepts "Vezetéknév Keresztnév" and reversed order, and payment prefixes followed
+// by a concatenated name such as "SIMPLEP baloghekszer"
+func isPersonName(name string) bool {
+	tokens := textnorm.Tokens(name)
+
+	hasPaymentPrefix := false
+	for len(tokens) > 0 && paymentPrefixes[tokens[0]] {
+		tokens = tokens[1:]
+		hasPaymentPrefix = true
+	}
+
+	if len(tokens) == 0 || len(tokens) > 4 {
+		return false
+	}
+
+	for _, token := range tokens {
+		if businessMarkers[token] || !isAlphabetic(token) {
+			return false
+		}
+	}
+
+	if hasPaymentPrefix && len(tokens) == 1 {
+		return startsWithName(tokens[0])
+	}
+
+	if len(tokens) < 2 {
+		return false
+	}
+
+	// Needs a given name plus a surname or second given name, in any order
+	given := 0
+	surname := 0
+	for _, token := range tokens {
+		switch {
+		case givenNames[token]:
+			given++
+		case surnames[token]:
+			surname++
+		}
+	}
+	return given >= 1 && given+surname >= 2
+}
+
+// startsWithName detects a surname or given name concatenated with something else
+// Short surnames need a given name after them ("kisspeter"), so "nagyker" is not flagged
+func startsWithName(token string) bool {
+	for prefixLen := len(token); prefixLen >= 4; prefixLen-- {
+		prefix, rest := token[:prefixLen], token[prefixLen:]
+		if !surnames[prefix] && !givenNames[prefix] {
+			continue
+		}
+		if rest == "" || givenNames[rest] || len(prefix) >= 5 {
+			return true
+		}
+	}
+	return false
+}
+
+// isOwnerName compares names accent- and case-insensitively in any word order
+func isOwnerName(name, ownerName string) bool {
+	a, b := textnorm.Tokens(name), textnorm.Tokens(ownerName)
+	if len(a) == 0 || len(a) != len(b) {
+		return false
+	}
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ownerNamePattern matches the owner's name in free text accent-insensitively, in either word order
+func ownerNamePattern(ownerName string) *regexp.Regexp {
+	words := strings.Fields(ownerName)
+	if len(words) == 0 {
+		return nil
+	}
+
+	wordPatterns := make([]string, len(words))
+	for i, word := range words {
+		wordPatterns[i] = accentInsensitive(word)
+	}
+
+	alternatives := []string{strings.Join(wordPatterns, `\s+`)}
+	if len(words) > 1 {
+		reversed := make([]string, len(wordPatterns))
+		for i, p := range wordPatterns {
+			reversed[len(wordPatterns)-1-i] = p
+		}
+		alternatives = append(alternatives, strings.Join(reversed, `\s+`))
+	}
+
+	return regexp.MustCompile(`(?i)(?:^|[^\pL])(` + strings.Join(alternatives, "|") + `)(?:$|[^\pL])`)
+}
+
+// accentInsensitive builds a pattern where each vowel also matches its accented variants
+func accentInsensitive(word string) string {
+	variants := map[rune]string{
+		'a': "aá", 'e': "eé", 'i': "ií", 'o': "oóöő", 'u': "uúüű",
+	}
+
+	var b strings.Builder
+	for _, r := range textnorm.Fold(word) {
+		if v, ok := variants[r]; ok {
+			b.WriteString("[" + v + "]")
+		} else {
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+func isAlphabetic(token string) bool {
+	for _, r := range token {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
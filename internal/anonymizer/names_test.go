@@ -0,0 +1,75 @@
+package anonymizer
+
+import "testing"
+
+func TestIsPersonName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Nagy Anna", true},
+		{"Anna Nagy", true},
+		{"KISS PÉTER", true},
+		{"Szabó Éva Mária", true},
+		{"SIMPLEP baloghekszer", true},
+		{"SIMPLEP kisspeter", true},
+		{"Nagy Kiss", false},
+		{"TESCO ÁRUHÁZ", false},
+		{"Kovács és Társa Kft", false},
+		{"SIMPLEP nagyker", false},
+		{"Anna", false},
+		{"MOL 63150", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPersonName(tt.name); got != tt.want {
+			t.Errorf("isPersonName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOwnerName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Kovács Béla", true},
+		{"KOVACS BELA", true},
+		{"Béla Kovács", true},
+		{"Kovács Béla Péter", false},
+		{"Kovács Anna", false},
+	}
+	for _, tt := range tests {
+		if got := isOwnerName(tt.name, "Kovács Béla"); got != tt.want {
+			t.Errorf("isOwnerName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Without a mapping every spelling gets the generic placeholder
+	text, findings := AnonymizeText("Utalás KOVÁCS BÉLA és Béla Kovacs számlájára", &Config{OwnerNames: []string{"Kovács Béla"}})
+	if want := "Utalás [OWNER_NAME] és [OWNER_NAME] számlájára"; text != want || len(findings) != 2 {
+		t.Errorf("AnonymizeText = %q (%d findings), want %q", text, len(findings), want)
+	}
+}
+
+func TestAnonymizeDetectionTypes(t *testing.T) {
+	cfg := &Config{OwnerNames: []string{"Kovács Béla"}, Mapping: NewMapping()}
+
+	tests := []struct {
+		partner, transactionType string
+		anonymized, kind         string
+	}{
+		{"TESCO ÁRUHÁZ", "Kártyás vásárlás", "TESCO ÁRUHÁZ", "none"},
+		{"Kovács Béla", "Átutalás", "[OWNER_1]", "owner_name"},
+		{"Kiss Ferenc", "Átutalás jóváírás", "[PERSON_1]", "transfer_partner"},
+		{"Nagy Anna", "Kártyás vásárlás", "[PERSON_2]", "personal_name"},
+		{"HU42117730161111101800000000", "Átutalás", "[ACCOUNT_1]", "account_number"},
+		{"Kiss Ferenc", "Átutalás", "[PERSON_1]", "transfer_partner"},
+	}
+	for _, tt := range tests {
+		result := Anonymize(tt.partner, tt.transactionType, cfg)
+		if result.Anonymized != tt.anonymized || result.DetectionType != tt.kind || result.IsPersonal != (tt.kind != "none") {
+			t.Errorf("Anonymize(%q, %q) = %+v, want %s (%s)", tt.partner, tt.transactionType, result, tt.anonymized, tt.kind)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package categorizer
+
+// automaton finds every pattern contained in a text in a single pass (Aho–Corasick)
+// Patterns and texts are compared byte by byte, so callers lowercase both
+type automaton struct {
+	next    []map[byte]int // Goto function, one map of transitions per state
+	fail    []int          // Longest proper suffix of the state that is also a state
+	outputs [][]int        // Patterns ending in the state, including those of its fail states
+}
+
+// newAutomaton builds the automaton; pattern i is reported as i, empty patterns never match
+func newAutomaton(patterns []string) *automaton {
+	a := &automaton{
+		next:    []map[byte]int{{}},
+		fail:    []int{0},
+		outputs: [][]int{nil},
+	}
+
+	for id, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		state := 0
+		for i := 0; i < len(pattern); i++ {
+			child, ok := a.next[state][pattern[i]]
+			if !ok {
+				child = len(a.next)
+				a.next = append(a.next, map[byte]int{})
+				a.fail = append(a.fail, 0)
+				a.outputs = append(a.outputs, nil)
+				a.next[state][pattern[i]] = child
+			}
+			state = child
+		}
+		a.outputs[state] = append(a.outputs[state], id)
+	}
+
+	// Breadth-first, so the fail state of every state is complete before its children
+	queue := make([]int, 0, len(a.next))
+	for _, child := range a.next[0] {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for b, child := range a.next[state] {
+			queue = append(queue, child)
+			fail := a.fail[state]
+			for {
+				if target, ok := a.next[fail][b]; ok {
+					a.fail[child] = target
+					break
+				}
+				if fail == 0 {
+					break
+				}
+				fail = a.fail[fail]
+			}
+			a.outputs[child] = append(a.outputs[child], a.outputs[a.fail[child]]...)
+		}
+	}
+
+	return a
+}
+
+// match calls found for every occurrence of a pattern in text, a pattern can be reported repeatedly
+func (a *automaton) match(text string, found func(id int)) {
+	state := 0
+	for i := 0; i < len(text); i++ {
+		for {
+			if child, ok := a.next[state][text[i]]; ok {
+				state = child
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = a.fail[state]
+		}
+		for _, id := range a.outputs[state] {
+			found(id)
+		}
+	}
+}
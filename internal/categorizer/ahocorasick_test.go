@@ -0,0 +1,73 @@
+package categorizer
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// occurrences counts every, possibly overlapping, occurrence of each pattern the naive way
+func occurrences(patterns []string, text string) map[int]int {
+	counts := make(map[int]int)
+	for id, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		for i := 0; i+len(pattern) <= len(text); i++ {
+			if strings.HasPrefix(text[i:], pattern) {
+				counts[id]++
+			}
+		}
+	}
+	return counts
+}
+
+func automatonOccurrences(a *automaton, text string) map[int]int {
+	counts := make(map[int]int)
+	a.match(text, func(id int) { counts[id]++ })
+	return counts
+}
+
+func TestAutomaton(t *testing.T) {
+	// The classic example: "she" ends in "he", "hers" needs the fail link from "she" to "he"
+	patterns := []string{"he", "she", "his", "hers", "", "he", "á", "ás"}
+	a := newAutomaton(patterns)
+
+	for _, text := range []string{"ushers", "ahishers", "hhhe", "shhis", "", "h", "kártyás vásárlás", "xyz"} {
+		if got, want := automatonOccurrences(a, text), occurrences(patterns, text); !reflect.DeepEqual(got, want) {
+			t.Errorf("match(%q) = %v, want %v", text, got, want)
+		}
+	}
+
+	if got := automatonOccurrences(newAutomaton(nil), "anything"); len(got) != 0 {
+		t.Errorf("empty automaton matched %v", got)
+	}
+}
+
+func TestAutomatonRandom(t *testing.T) {
+	// A small alphabet gives many overlapping and nested patterns
+	rng := rand.New(rand.NewSource(1))
+	randomString := func(maxLen int) string {
+		b := make([]byte, rng.Intn(maxLen+1))
+		for i := range b {
+			b[i] = "abc"[rng.Intn(3)]
+		}
+		return string(b)
+	}
+
+	for round := 0; round < 200; round++ {
+		patterns := make([]string, 1+rng.Intn(20))
+		for i := range patterns {
+			patterns[i] = randomString(5)
+		}
+		a := newAutomaton(patterns)
+
+		for i := 0; i < 20; i++ {
+			text := randomString(40)
+			if got, want := automatonOccurrences(a, text), occurrences(patterns, text); !reflect.DeepEqual(got, want) {
+				t.Fatalf("patterns %q, match(%q) = %v, want %v", patterns, text, got, want)
+			}
+		}
+	}
+}
@@ -1,69 +1,221 @@
 package categorizer
 
 import (
+	"sort"
 	"strings"
 
+	"ezbook-convert/internal/classifier"
 	"ezbook-convert/internal/config"
 )
 
 // Categorizer handles transaction categorization
+// It caches fuzzy results and is not safe for concurrent use
 type Categorizer struct {
-	config *config.Config
+	config        *config.Config
+	categoryNames []string
+	merchants     []merchant
+
+	// Indexes built once by New, so lookups don't scan the whole config per transaction
+	knownPartners map[string]bool
+	exactIndex    map[string][]rule
+	keywords      []rule
+	keywordIndex  *automaton
+
+	references []reference
+	threshold  float64
+	fuzzyCache map[string][]Match // Fuzzy results per canonical name, exports repeat the same partners
+
+	model         *classifier.Model
+	minConfidence float64
+}
+
+// Match describes which rule categorized a transaction
+type Match struct {
+	Category    string
+	SubCategory string
+	Merchant    string  // Canonical merchant name the partner was matched as
+	Tier        string  // "exact", "keyword", "fuzzy", "model", "type", "default"
+	Rule        string  // Exact match, keyword, known partner or type pattern that fired
+	Score       float64 // Similarity score for fuzzy matches, confidence for model matches
 }
 
 // New creates a new Categorizer
 func New(cfg *config.Config) *Categorizer {
-	return &Categorizer{config: cfg}
+	c := &Categorizer{config: cfg}
+	c.categoryNames = c.sortedCategories()
+	c.merchants = c.buildMerchants()
+	c.buildIndexes()
+
+	if cfg.Fuzzy != nil {
+		c.threshold = cfg.Fuzzy.Threshold
+		if c.threshold <= 0 {
+			c.threshold = config.DefaultFuzzyThreshold
+		}
+		c.references = c.buildReferences()
+		c.fuzzyCache = make(map[string][]Match)
+	}
+
+	return c
+}
+
+// SetClassifier enables the statistical fallback tier
+// Predictions below minConfidence are ignored, 0 uses config.DefaultMinConfidence
+func (c *Categorizer) SetClassifier(model *classifier.Model, minConfidence float64) {
+	if minConfidence <= 0 {
+		minConfidence = config.DefaultMinConfidence
+	}
+	c.model = model
+	c.minConfidence = minConfidence
 }
 
 // Categorize determines the category for a transaction
 // Returns main category, subcategory, or ("Uncategorized", "") if no match found
 func (c *Categorizer) Categorize(partnerName, transactionType string) (string, string) {
-	partnerLower := strings.ToLower(partnerName)
-	typeLower := strings.ToLower(transactionType)
+	match := c.Match(partnerName, transactionType, "")
+	return match.Category, match.SubCategory
+}
 
-	// Priority 1: Exact match
-	for categoryName, category := range c.config.Categories {
-		for _, exactMatch := range category.ExactMatches {
-			if partnerName == exactMatch {
-				return categoryName, category.SubCategory
-			}
+// Match categorizes a transaction and reports the rule that fired
+// The description is only used by the classifier tier
+func (c *Categorizer) Match(partnerName, transactionType, description string) Match {
+	return c.Explain(partnerName, transactionType, description, false)[0]
+}
+
+// Explain returns the rules matching a transaction in priority order
+// The first entry is the match Categorize uses; with all=false only that entry is returned,
+// otherwise every rule that would also have matched follows it
+func (c *Categorizer) Explain(partnerName, transactionType, description string, all bool) []Match {
+	merchantName := c.Canonicalize(partnerName)
+
+	// Raw name first, then the canonical name if it differs
+	names := []string{partnerName}
+	if merchantName != partnerName {
+		names = append(names, merchantName)
+	}
+
+	tiers := []func() []Match{
+		// Priority 1: Exact match on raw or canonical name
+		func() []Match { return c.exactMatches(names) },
+		// Priority 2: Keyword match in partner name
+		func() []Match { return c.keywordMatches(names) },
+		// Priority 3: Fuzzy match against known merchants (optional)
+		func() []Match { return c.fuzzyMatches(merchantName) },
+		// Priority 4: Statistical classifier trained on history (optional)
+		func() []Match { return c.modelMatches(merchantName, transactionType, description) },
+		// Priority 5: Transaction type fallback
+		func() []Match { return c.typeMatches(transactionType) },
+	}
+
+	var matches []Match
+	for _, tier := range tiers {
+		matches = append(matches, tier()...)
+		if len(matches) > 0 && !all {
+			break
 		}
 	}
 
-	// Priority 2: Keyword match in partner name
-	for categoryName, category := range c.config.Categories {
-		for _, keyword := range category.Keywords {
-			keywordLower := strings.ToLower(keyword)
-			if strings.Contains(partnerLower, keywordLower) {
-				return categoryName, category.SubCategory
+	// Default: Miscellaneous with type-specific subcategory
+	// This will be determined based on transaction amount sign in converter
+	if len(matches) == 0 {
+		matches = append(matches, Match{Category: "Miscellaneous", Tier: "default"})
+	}
+
+	for i := range matches {
+		matches[i].Merchant = merchantName
+	}
+	if !all {
+		matches = matches[:1]
+	}
+	return matches
+}
+
+// sortedCategories returns category names in a stable order so the first match is deterministic
+func (c *Categorizer) sortedCategories() []string {
+	names := make([]string, 0, len(c.config.Categories))
+	for name := range c.config.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Categorizer) exactMatches(names []string) []Match {
+	var matches []Match
+	seen := make(map[rule]bool)
+
+	for _, name := range names {
+		for _, r := range c.exactIndex[name] {
+			if seen[r] {
+				continue
 			}
+			seen[r] = true
+			matches = append(matches, r.match("exact"))
 		}
 	}
+	return matches
+}
 
-	// Priority 3: Transaction type fallback
-	if strings.Contains(typeLower, "jóváírás") || strings.Contains(typeLower, "fizetés") {
-		return "Miscellaneous", "Other Income"
+func (c *Categorizer) keywordMatches(names []string) []Match {
+	var matches []Match
+	seen := make(map[int]bool)
+
+	for _, name := range names {
+		// Report keywords in config order, not in the order they occur in the name
+		var found []int
+		c.keywordIndex.match(strings.ToLower(name), func(id int) {
+			if !seen[id] {
+				seen[id] = true
+				found = append(found, id)
+			}
+		})
+		sort.Ints(found)
+		for _, id := range found {
+			matches = append(matches, c.keywords[id].match("keyword"))
+		}
 	}
+	return matches
+}
 
-	if strings.Contains(typeLower, "hitel törlesztés") {
-		return "Finance & Insurance", "Interest Expense"
+func (c *Categorizer) modelMatches(merchantName, transactionType, description string) []Match {
+	if c.model == nil {
+		return nil
 	}
 
-	if strings.Contains(typeLower, "készpénz") {
-		return "General Transfer", "Deposits & Withdrawals"
+	prediction, ok := c.model.Predict(merchantName, transactionType, description)
+	if !ok || prediction.Confidence < c.minConfidence {
+		return nil
 	}
 
-	if strings.Contains(typeLower, "díj") || strings.Contains(typeLower, "költség") {
-		return "Finance & Insurance", "Service Charge"
-	}
+	return []Match{{
+		Category:    prediction.Category,
+		SubCategory: prediction.SubCategory,
+		Tier:        "model",
+		Rule:        "naive bayes",
+		Score:       prediction.Confidence,
+	}}
+}
 
-	// Default: Miscellaneous with type-specific subcategory
-	// This will be determined based on transaction amount sign in converter
-	return "Miscellaneous", ""
+func (c *Categorizer) typeMatches(transactionType string) []Match {
+	typeLower := strings.ToLower(transactionType)
+
+	var matches []Match
+	for _, rule := range c.config.TypeRules {
+		for _, pattern := range rule.Patterns {
+			if strings.Contains(typeLower, strings.ToLower(pattern)) {
+				matches = append(matches, Match{
+					Category:    rule.Category,
+					SubCategory: rule.SubCategory,
+					Tier:        "type",
+					Rule:        pattern,
+				})
+			}
+		}
+	}
+	return matches
 }
 
 // GetUncategorizedPartners finds partners not in known_partners list
+// Partners are reported by their canonical merchant name
 func (c *Categorizer) GetUncategorizedPartners(partners []string) []string {
 	var uncategorized []string
 	seen := make(map[string]bool)
@@ -73,19 +225,20 @@ func (c *Categorizer) GetUncategorizedPartners(partners []string) []string {
 		if partner == "" {
 			continue
 		}
+		merchantName := c.Canonicalize(partner)
 
 		// Skip if already seen
-		if seen[partner] {
+		if seen[merchantName] {
 			continue
 		}
-		seen[partner] = true
+		seen[merchantName] = true
 
-		// Skip if in known partners
-		if c.config.IsKnownPartner(partner) {
+		// Skip if in known partners (either raw or canonical name)
+		if c.knownPartners[partner] || c.knownPartners[merchantName] {
 			continue
 		}
 
-		uncategorized = append(uncategorized, partner)
+		uncategorized = append(uncategorized, merchantName)
 	}
 
 	return uncategorized
@@ -0,0 +1,130 @@
+package categorizer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"ezbook-convert/internal/config"
+)
+
+func explainConfig() *config.Config {
+	cfg := config.New()
+	cfg.Categories["Food & Drink"] = &config.Category{
+		SubCategory:  "Groceries",
+		Keywords:     []string{"tesco", "áruház"},
+		ExactMatches: []string{"TESCO ÁRUHÁZ"},
+	}
+	cfg.Categories["Clothing & Appearance"] = &config.Category{
+		SubCategory: "Clothing",
+		Keywords:    []string{"áruház"},
+	}
+	return cfg
+}
+
+func TestExplain(t *testing.T) {
+	c := New(explainConfig())
+
+	var got []string
+	for _, m := range c.Explain("TESCO ÁRUHÁZ", "Kártyás díj", "", true) {
+		got = append(got, m.Tier+" "+m.Category+" "+m.Rule)
+	}
+	want := []string{
+		"exact Food & Drink TESCO ÁRUHÁZ",
+		// Keywords are reported in category order, then config order
+		"keyword Clothing & Appearance áruház",
+		"keyword Food & Drink tesco",
+		"keyword Food & Drink áruház",
+		"type Finance & Insurance díj",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Explain =\n%q\nwant\n%q", got, want)
+	}
+
+	if matches := c.Explain("TESCO ÁRUHÁZ", "", "", false); len(matches) != 1 || matches[0].Tier != "exact" {
+		t.Errorf("Explain(all=false) = %+v, want only the exact match", matches)
+	}
+}
+
+func TestMatchFallbacks(t *testing.T) {
+	c := New(explainConfig())
+
+	tests := []struct {
+		partner, transactionType string
+		category, tier           string
+	}{
+		{"tesco express", "", "Food & Drink", "keyword"},
+		{"Kovács Béla", "Átutalás jóváírás", "Miscellaneous", "type"},
+		{"Kovács Béla", "Átutalás", "Miscellaneous", "default"},
+	}
+	for _, tt := range tests {
+		match := c.Match(tt.partner, tt.transactionType, "")
+		if match.Category != tt.category || match.Tier != tt.tier {
+			t.Errorf("Match(%q, %q) = %+v, want %s (%s)", tt.partner, tt.transactionType, match, tt.category, tt.tier)
+		}
+	}
+}
+
+// benchmarkConfig has n known partners, n keywords and n exact matches spread over 20 categories
+func benchmarkConfig(n int) (*config.Config, []string) {
+	cfg := config.New()
+	partners := make([]string, n)
+	for i := 0; i < n; i++ {
+		partners[i] = fmt.Sprintf("PARTNER %d KFT", i)
+		category := cfg.Categories[fmt.Sprintf("Category %d", i%20)]
+		if category == nil {
+			category = &config.Category{SubCategory: "Other"}
+			cfg.Categories[fmt.Sprintf("Category %d", i%20)] = category
+		}
+		category.Keywords = append(category.Keywords, fmt.Sprintf("keyword%d", i))
+		category.ExactMatches = append(category.ExactMatches, fmt.Sprintf("PARTNER %d", i))
+		cfg.KnownPartners = append(cfg.KnownPartners, fmt.Sprintf("PARTNER %d", i))
+	}
+	return cfg, partners
+}
+
+func BenchmarkCategorize(b *testing.B) {
+	for _, n := range []int{1000, 4000, 16000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			cfg, partners := benchmarkConfig(n)
+			c := New(cfg)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Categorize(partners[i%n], "Kártyás vásárlás")
+			}
+		})
+	}
+}
+
+func BenchmarkCategorizeFuzzy(b *testing.B) {
+	for _, n := range []int{1000, 4000, 16000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			cfg, _ := benchmarkConfig(n)
+			cfg.Fuzzy = &config.FuzzyConfig{Threshold: 0.8}
+			c := New(cfg)
+
+			// Misspelled partners miss the exact and keyword tiers; a real export repeats a few hundred of them
+			partners := make([]string, 500)
+			for i := range partners {
+				partners[i] = fmt.Sprintf("PARTNR %d", i*n/len(partners))
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Categorize(partners[i%len(partners)], "Kártyás vásárlás")
+			}
+		})
+	}
+}
+
+func BenchmarkGetUncategorizedPartners(b *testing.B) {
+	for _, n := range []int{1000, 4000, 16000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			cfg, partners := benchmarkConfig(n)
+			c := New(cfg)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.GetUncategorizedPartners(partners)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package categorizer
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"ezbook-convert/internal/textnorm"
+)
+
+// reference is a known merchant name with the category it resolves to
+type reference struct {
+	name        string
+	tokens      []string
+	category    string
+	subCategory string
+}
+
+// noiseTokens are dropped before comparing merchant names
+// K&H appends store numbers and legal forms that differ between branches
+var noiseTokens = map[string]bool{
+	"sz":   true,
+	"kft":  true,
+	"zrt":  true,
+	"nyrt": true,
+	"bt":   true,
+	"kkt":  true,
+}
+
+// buildReferences collects exact matches and known partners that resolve to a category
+func (c *Categorizer) buildReferences() []reference {
+	var refs []reference
+	seen := make(map[string]bool)
+
+	add := func(name, category, subCategory string) {
+		if seen[name] {
+			return
+		}
+		tokens := tokenize(name)
+		if len(tokens) == 0 {
+			return
+		}
+		seen[name] = true
+		refs = append(refs, reference{
+			name:        name,
+			tokens:      tokens,
+			category:    category,
+			subCategory: subCategory,
+		})
+	}
+
+	for _, categoryName := range c.categoryNames {
+		category := c.config.Categories[categoryName]
+		if category == nil {
+			continue
+		}
+		for _, exactMatch := range category.ExactMatches {
+			add(exactMatch, categoryName, category.SubCategory)
+		}
+	}
+
+	for _, partner := range c.config.KnownPartners {
+		matches := c.exactMatches([]string{partner})
+		if len(matches) == 0 {
+			matches = c.keywordMatches([]string{partner})
+		}
+		if len(matches) > 0 {
+			add(partner, matches[0].Category, matches[0].SubCategory)
+		}
+	}
+
+	// Keep results deterministic regardless of map iteration order
+	sort.Slice(refs, func(i, j int) bool { return refs[i].name < refs[j].name })
+
+	return refs
+}
+
+// fuzzyMatches finds the most similar known merchant above the threshold
+// Results are cached per name, since comparing against every reference is the slowest tier
+func (c *Categorizer) fuzzyMatches(partnerName string) []Match {
+	if len(c.references) == 0 {
+		return nil
+	}
+	if matches, ok := c.fuzzyCache[partnerName]; ok {
+		return matches
+	}
+
+	matches := c.bestReference(partnerName)
+	c.fuzzyCache[partnerName] = matches
+	return matches
+}
+
+// bestReference compares a name against every known merchant
+func (c *Categorizer) bestReference(partnerName string) []Match {
+	tokens := tokenize(partnerName)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var best *reference
+	bestScore := 0.0
+	for i := range c.references {
+		score := similarity(tokens, c.references[i].tokens)
+		if score > bestScore {
+			best = &c.references[i]
+			bestScore = score
+		}
+	}
+
+	if best == nil || bestScore < c.threshold {
+		return nil
+	}
+
+	return []Match{{
+		Category:    best.category,
+		SubCategory: best.subCategory,
+		Tier:        "fuzzy",
+		Rule:        best.name,
+		Score:       bestScore,
+	}}
+}
+
+// tokenize lowercases and splits a merchant name, dropping store numbers and legal forms
+func tokenize(name string) []string {
+	var tokens []string
+	for _, field := range textnorm.Tokens(name) {
+		if noiseTokens[field] || isNumeric(field) {
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+// similarity returns the better of token overlap and edit distance similarity
+func similarity(a, b []string) float64 {
+	score := jaccard(a, b)
+	if edit := editSimilarity(strings.Join(a, " "), strings.Join(b, " ")); edit > score {
+		score = edit
+	}
+	return score
+}
+
+func jaccard(a, b []string) float64 {
+	setA := make(map[string]bool, len(a))
+	for _, t := range a {
+		setA[t] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, t := range b {
+		setB[t] = true
+	}
+
+	intersection := 0
+	for t := range setA {
+		if setB[t] {
+			intersection++
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// editSimilarity normalizes the Levenshtein distance to a 0-1 score
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package categorizer
+
+import (
+	"testing"
+
+	"ezbook-convert/internal/config"
+)
+
+func fuzzyConfig() *config.Config {
+	cfg := config.New()
+	cfg.Fuzzy = &config.FuzzyConfig{}
+	cfg.Categories["Food & Drink"] = &config.Category{
+		SubCategory:  "Groceries",
+		ExactMatches: []string{"TESCO ÁRUHÁZ 41028"},
+	}
+	cfg.Categories["Transportation"] = &config.Category{
+		SubCategory: "Fuel",
+		Keywords:    []string{"mol"},
+	}
+	cfg.KnownPartners = []string{"MOL TÖLTŐÁLLOMÁS"}
+	return cfg
+}
+
+func TestFuzzyMatches(t *testing.T) {
+	c := New(fuzzyConfig())
+
+	tests := []struct {
+		partner     string
+		category    string
+		subCategory string
+		tier        string
+	}{
+		{"TESCO ÁRUHÁZ 41028", "Food & Drink", "Groceries", "exact"},
+		{"TESCO ARUHAZ 41999 KFT", "Food & Drink", "Groceries", "fuzzy"},
+		{"Tesco Áruház", "Food & Drink", "Groceries", "fuzzy"},
+		{"SPAR MARKET", "Miscellaneous", "", "default"},
+	}
+
+	for _, tt := range tests {
+		match := c.Match(tt.partner, "", "")
+		if match.Category != tt.category || match.SubCategory != tt.subCategory || match.Tier != tt.tier {
+			t.Errorf("Match(%q) = %s/%s (%s), want %s/%s (%s)",
+				tt.partner, match.Category, match.SubCategory, match.Tier, tt.category, tt.subCategory, tt.tier)
+		}
+	}
+}
+
+func TestFuzzyThreshold(t *testing.T) {
+	cfg := fuzzyConfig()
+	if match := New(cfg).Match("TESKO ARUHAZ", "", ""); match.Tier != "fuzzy" {
+		t.Errorf("Match with default threshold = %+v, want a fuzzy match", match)
+	}
+
+	cfg.Fuzzy.Threshold = 0.95
+	if match := New(cfg).Match("TESKO ARUHAZ", "", ""); match.Tier == "fuzzy" {
+		t.Errorf("Match with threshold 0.95 = %+v, want no fuzzy match", match)
+	}
+}
+
+func TestFuzzyCache(t *testing.T) {
+	c := New(fuzzyConfig())
+
+	first := c.Match("TESCO ARUHAZ 41999 KFT", "", "")
+	if len(c.fuzzyCache) != 1 {
+		t.Fatalf("fuzzyCache has %d entries after one fuzzy match, want 1", len(c.fuzzyCache))
+	}
+	if second := c.Match("TESCO ARUHAZ 41999 KFT", "", ""); second != first {
+		t.Errorf("cached Match = %+v, want %+v", second, first)
+	}
+	if explained := c.Explain("TESCO ARUHAZ 41999 KFT", "", "", true); explained[0] != first {
+		t.Errorf("Explain after a cached match = %+v, want %+v first", explained, first)
+	}
+}
+
+func TestFuzzySkipsNullCategory(t *testing.T) {
+	cfg := fuzzyConfig()
+	cfg.Categories["Empty"] = nil
+
+	c := New(cfg)
+	if match := c.Match("TESCO ARUHAZ", "", ""); match.Category != "Food & Drink" {
+		t.Errorf("Match = %+v, want Food & Drink", match)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"tesco aruhaz", "tesco aruhaz", 1, 1},
+		{"tesco aruhaz 123 kft", "tesco aruhaz", 1, 1},
+		{"tesco", "tesko", 0.8, 0.8},
+		{"tesco", "spar", 0, 0.2},
+	}
+
+	for _, tt := range tests {
+		score := similarity(tokenize(tt.a), tokenize(tt.b))
+		if score < tt.min || score > tt.max {
+			t.Errorf("similarity(%q, %q) = %.2f, want %.2f-%.2f", tt.a, tt.b, score, tt.min, tt.max)
+		}
+	}
+}
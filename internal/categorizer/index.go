@@ -0,0 +1,48 @@
+package categorizer
+
+import "strings"
+
+// rule is an exact match or keyword of a category
+type rule struct {
+	category    string
+	subCategory string
+	value       string
+}
+
+func (r rule) match(tier string) Match {
+	return Match{
+		Category:    r.category,
+		SubCategory: r.subCategory,
+		Tier:        tier,
+		Rule:        r.value,
+	}
+}
+
+// buildIndexes builds the known partner and exact match sets and the keyword automaton
+// Keywords are numbered in category order, so matches keep the priority of the config
+func (c *Categorizer) buildIndexes() {
+	c.knownPartners = make(map[string]bool, len(c.config.KnownPartners))
+	for _, partner := range c.config.KnownPartners {
+		c.knownPartners[partner] = true
+	}
+
+	c.exactIndex = make(map[string][]rule)
+	c.keywords = nil
+	var patterns []string
+
+	for _, categoryName := range c.categoryNames {
+		category := c.config.Categories[categoryName]
+		if category == nil {
+			continue
+		}
+		for _, exactMatch := range category.ExactMatches {
+			c.exactIndex[exactMatch] = append(c.exactIndex[exactMatch], rule{categoryName, category.SubCategory, exactMatch})
+		}
+		for _, keyword := range category.Keywords {
+			c.keywords = append(c.keywords, rule{categoryName, category.SubCategory, keyword})
+			patterns = append(patterns, strings.ToLower(keyword))
+		}
+	}
+
+	c.keywordIndex = newAutomaton(patterns)
+}
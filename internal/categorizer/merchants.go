@@ -0,0 +1,40 @@
+package categorizer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// merchant is a canonical merchant name with its compiled patterns
+type merchant struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+// buildMerchants compiles the merchant table from the config
+// Patterns are validated by config.LoadConfig, invalid ones are skipped here
+func (c *Categorizer) buildMerchants() []merchant {
+	var merchants []merchant
+	for _, m := range c.config.Merchants {
+		patterns, err := m.CompilePatterns()
+		if err != nil || m.Name == "" {
+			continue
+		}
+		merchants = append(merchants, merchant{name: m.Name, patterns: patterns})
+	}
+	return merchants
+}
+
+// Canonicalize returns the canonical merchant name for a raw partner name
+// Returns the trimmed partner name unchanged if no merchant pattern matches
+func (c *Categorizer) Canonicalize(partnerName string) string {
+	partnerName = strings.TrimSpace(partnerName)
+	for _, m := range c.merchants {
+		for _, pattern := range m.patterns {
+			if pattern.MatchString(partnerName) {
+				return m.name
+			}
+		}
+	}
+	return partnerName
+}
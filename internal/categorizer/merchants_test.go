@@ -0,0 +1,39 @@
+package categorizer
+
+import (
+	"testing"
+
+	"ezbook-convert/internal/config"
+)
+
+func TestCanonicalize(t *testing.T) {
+	cfg := config.New()
+	cfg.Merchants = []*config.Merchant{
+		{Name: "Tesco", Patterns: []string{`^tesco\b`, `^tesco-global`}},
+		{Name: "Broken", Patterns: []string{`(`}},
+		{Name: "Lidl", Patterns: []string{`lidl`}},
+	}
+	cfg.Categories["Food & Drink"] = &config.Category{SubCategory: "Groceries", ExactMatches: []string{"Tesco"}}
+	c := New(cfg)
+
+	tests := []struct {
+		partner string
+		want    string
+	}{
+		{"TESCO ÁRUHÁZ 41028", "Tesco"},
+		{"  Tesco-Global Áruházak Zrt.", "Tesco"},
+		{"LIDL ÁRUHÁZ 0123", "Lidl"},
+		{"ALDI 123", "ALDI 123"},
+		{"  SPAR  ", "SPAR"},
+	}
+	for _, tt := range tests {
+		if got := c.Canonicalize(tt.partner); got != tt.want {
+			t.Errorf("Canonicalize(%q) = %q, want %q", tt.partner, got, tt.want)
+		}
+	}
+
+	// Exact matches apply to the canonical name as well as the raw one
+	if match := c.Match("TESCO ÁRUHÁZ 41028", "", ""); match.Category != "Food & Drink" || match.Merchant != "Tesco" {
+		t.Errorf("Match = %+v, want Food & Drink via merchant Tesco", match)
+	}
+}
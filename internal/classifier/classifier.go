@@ -0,0 +1,184 @@
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Example is a categorized transaction used for training
+type Example struct {
+	Category    string
+	SubCategory string
+	PartnerName string
+	Type        string
+	Description string
+}
+
+// Prediction is the most likely category with its posterior probability
+type Prediction struct {
+	Category    string
+	SubCategory string
+	Confidence  float64
+}
+
+// Model is a multinomial Naive Bayes model over transaction features
+type Model struct {
+	Classes    []*Class `json:"classes"`
+	Vocabulary int      `json:"vocabulary"`
+	Documents  int      `json:"documents"`
+}
+
+// Class holds the feature counts for a category/subcategory pair
+type Class struct {
+	Category    string         `json:"category"`
+	SubCategory string         `json:"subcategory"`
+	Documents   int            `json:"documents"`
+	TokenCount  int            `json:"token_count"`
+	Tokens      map[string]int `json:"tokens"`
+}
+
+// Train builds a model from categorized examples
+func Train(examples []Example) *Model {
+	classes := make(map[string]*Class)
+	vocabulary := make(map[string]bool)
+	model := &Model{}
+
+	for _, example := range examples {
+		features := Features(example.PartnerName, example.Type, example.Description)
+		if len(features) == 0 {
+			continue
+		}
+
+		key := example.Category + "\x00" + example.SubCategory
+		class, ok := classes[key]
+		if !ok {
+			class = &Class{
+				Category:    example.Category,
+				SubCategory: example.SubCategory,
+				Tokens:      make(map[string]int),
+			}
+			classes[key] = class
+			model.Classes = append(model.Classes, class)
+		}
+
+		class.Documents++
+		model.Documents++
+		for _, feature := range features {
+			class.Tokens[feature]++
+			class.TokenCount++
+			vocabulary[feature] = true
+		}
+	}
+
+	model.Vocabulary = len(vocabulary)
+
+	// Keep the model file stable between trainings on the same data
+	sort.Slice(model.Classes, func(i, j int) bool {
+		if model.Classes[i].Category != model.Classes[j].Category {
+			return model.Classes[i].Category < model.Classes[j].Category
+		}
+		return model.Classes[i].SubCategory < model.Classes[j].SubCategory
+	})
+
+	return model
+}
+
+// Predict returns the most likely category for a transaction
+// Returns ok=false if the model is empty or no feature could be extracted
+func (m *Model) Predict(partnerName, transactionType, description string) (Prediction, bool) {
+	features := Features(partnerName, transactionType, description)
+	if len(m.Classes) == 0 || len(features) == 0 {
+		return Prediction{}, false
+	}
+
+	// Log-probabilities with Laplace smoothing
+	scores := make([]float64, len(m.Classes))
+	for i, class := range m.Classes {
+		score := math.Log(float64(class.Documents) / float64(m.Documents))
+		denominator := float64(class.TokenCount + m.Vocabulary)
+		for _, feature := range features {
+			score += math.Log(float64(class.Tokens[feature]+1) / denominator)
+		}
+		scores[i] = score
+	}
+
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+
+	// Normalize to a posterior probability (log-sum-exp)
+	total := 0.0
+	for _, score := range scores {
+		total += math.Exp(score - scores[best])
+	}
+
+	return Prediction{
+		Category:    m.Classes[best].Category,
+		SubCategory: m.Classes[best].SubCategory,
+		Confidence:  1 / total,
+	}, true
+}
+
+// Features extracts prefixed tokens from partner name, type and description
+func Features(partnerName, transactionType, description string) []string {
+	var features []string
+	for _, token := range tokenize(partnerName) {
+		features = append(features, "p:"+token)
+	}
+	for _, token := range tokenize(description) {
+		features = append(features, "d:"+token)
+	}
+	if t := strings.ToLower(strings.TrimSpace(transactionType)); t != "" {
+		features = append(features, "t:"+t)
+	}
+	return features
+}
+
+// tokenize lowercases and splits text, dropping numbers and single characters
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var tokens []string
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || strings.IndexFunc(field, unicode.IsLetter) < 0 {
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+// LoadModel reads a model file written by SaveModel
+func LoadModel(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var model Model
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("invalid model file: %w", err)
+	}
+
+	return &model, nil
+}
+
+// SaveModel writes the model as JSON
+func SaveModel(path string, model *Model) error {
+	data, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,72 @@
+package classifier
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var examples = []Example{
+	{Category: "Food & Drink", SubCategory: "Groceries", PartnerName: "TESCO ÁRUHÁZ 41028", Type: "Kártyás vásárlás"},
+	{Category: "Food & Drink", SubCategory: "Groceries", PartnerName: "SPAR MARKET 123", Type: "Kártyás vásárlás"},
+	{Category: "Food & Drink", SubCategory: "Groceries", PartnerName: "TESCO EXPRESS", Type: "Kártyás vásárlás"},
+	{Category: "Transportation", SubCategory: "Fuel", PartnerName: "MOL TÖLTŐÁLLOMÁS", Type: "Kártyás vásárlás"},
+	{Category: "Transportation", SubCategory: "Fuel", PartnerName: "OMV BENZINKÚT", Type: "Kártyás vásárlás"},
+	{Category: "Housing & Houseware", SubCategory: "Rent", PartnerName: "Kovács Béla", Type: "Átutalás", Description: "albérlet március"},
+}
+
+func TestFeatures(t *testing.T) {
+	got := Features("TESCO Áruház 41028 x", " Kártyás vásárlás ", "Bérleti díj")
+	want := []string{"p:tesco", "p:áruház", "d:bérleti", "d:díj", "t:kártyás vásárlás"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Features = %q, want %q", got, want)
+	}
+}
+
+func TestPredict(t *testing.T) {
+	model := Train(examples)
+	if model.Documents != len(examples) || len(model.Classes) != 3 {
+		t.Fatalf("Train: %d documents in %d classes, want %d in 3", model.Documents, len(model.Classes), len(examples))
+	}
+
+	tests := []struct {
+		partner, transactionType, description string
+		category                              string
+	}{
+		{"TESCO ÁRUHÁZ 99999", "Kártyás vásárlás", "", "Food & Drink"},
+		{"MOL 4411", "Kártyás vásárlás", "", "Transportation"},
+		{"Nagy Anna", "Átutalás", "albérlet április", "Housing & Houseware"},
+	}
+	for _, tt := range tests {
+		prediction, ok := model.Predict(tt.partner, tt.transactionType, tt.description)
+		if !ok || prediction.Category != tt.category {
+			t.Errorf("Predict(%q) = %+v, %v, want %s", tt.partner, prediction, ok, tt.category)
+		}
+		if prediction.Confidence <= 0 || prediction.Confidence > 1 {
+			t.Errorf("Predict(%q) confidence = %f, want 0-1", tt.partner, prediction.Confidence)
+		}
+	}
+
+	if _, ok := model.Predict("12", "", ""); ok {
+		t.Error("Predict without features should not predict")
+	}
+	if _, ok := Train(nil).Predict("TESCO", "", ""); ok {
+		t.Error("Predict on an empty model should not predict")
+	}
+}
+
+func TestSaveLoadModel(t *testing.T) {
+	model := Train(examples)
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := SaveModel(path, model); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadModel(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, model) {
+		t.Error("loaded model differs from the saved one")
+	}
+}
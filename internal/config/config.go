@@ -1,54 +1,171 @@
 package config
 
 import (
+	"fmt"
 	"os"
-
-	"gopkg.in/yaml.v3"
+	"regexp"
 )
 
 // Config represents the application configuration
 type Config struct {
+	Version       int                   `yaml:"version,omitempty"` // Config layout version, see CurrentVersion
+	Include       []string              `yaml:"include,omitempty"` // Other config files or directories, this file takes precedence
 	KnownPartners []string              `yaml:"known_partners"`
 	Categories    map[string]*Category  `yaml:"categories"`
+	Fuzzy         *FuzzyConfig          `yaml:"fuzzy,omitempty"`
+	Merchants     []*Merchant           `yaml:"merchants,omitempty"`
+	Classifier    *ClassifierConfig     `yaml:"classifier,omitempty"`
+	Owners        []string              `yaml:"owners,omitempty"` // Account owner names, anonymized in LLM prompts
+	TypeRules     []*TypeRule           `yaml:"type_rules,omitempty"`
+
+	ruleless map[string]*ValidationError // Where categories without rules are defined, see checkRules
+}
+
+// TypeRule categorizes by K&H transaction type when no other rule matches
+// Patterns are case-insensitive substrings of the type, e.g. "díj" for bank fees
+type TypeRule struct {
+	Patterns    []string `yaml:"patterns"`
+	Category    string   `yaml:"category"`
+	SubCategory string   `yaml:"subcategory,omitempty"`
+}
+
+// DefaultTypeRules are the type fallbacks of new configs, and the ones version 1 configs had built in
+var DefaultTypeRules = []*TypeRule{
+	{Patterns: []string{"jóváírás", "fizetés"}, Category: "Miscellaneous", SubCategory: "Other Income"},
+	{Patterns: []string{"hitel törlesztés"}, Category: "Finance & Insurance", SubCategory: "Interest Expense"},
+	{Patterns: []string{"készpénz"}, Category: "General Transfer", SubCategory: "Deposits & Withdrawals"},
+	{Patterns: []string{"díj", "költség"}, Category: "Finance & Insurance", SubCategory: "Service Charge"},
+}
+
+// New returns an empty config in the current layout, with the default type rules
+func New() *Config {
+	typeRules := make([]*TypeRule, len(DefaultTypeRules))
+	for i, rule := range DefaultTypeRules {
+		copied := *rule
+		typeRules[i] = &copied
+	}
+	return &Config{
+		Version:       CurrentVersion,
+		KnownPartners: []string{},
+		Categories:    make(map[string]*Category),
+		TypeRules:     typeRules,
+	}
 }
 
 // Category represents a transaction category with matching rules
 type Category struct {
 	SubCategory   string   `yaml:"subcategory,omitempty"`
-	Keywords      []string `yaml:"keywords"`
+	Keywords      []string `yaml:"keywords,omitempty"`
 	ExactMatches  []string `yaml:"exact_matches,omitempty"`
 }
 
-// LoadConfig reads and parses the YAML configuration file
+// Merchant maps raw K&H partner strings to a canonical merchant name
+// Patterns are case-insensitive regular expressions, the first matching merchant wins
+type Merchant struct {
+	Name     string   `yaml:"name"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// CompilePatterns compiles the merchant patterns as case-insensitive regexps
+func (m *Merchant) CompilePatterns() ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range m.Patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("merchant %q: invalid pattern %q: %w", m.Name, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// FuzzyConfig enables similarity matching against known merchants
+// Threshold is the minimum similarity score (0-1), defaults to DefaultFuzzyThreshold
+type FuzzyConfig struct {
+	Threshold float64 `yaml:"threshold,omitempty"`
+}
+
+// DefaultFuzzyThreshold is used when the fuzzy section has no threshold
+const DefaultFuzzyThreshold = 0.8
+
+// ClassifierConfig enables the Naive Bayes fallback tier
+// Model is a file written by the train command, relative paths are resolved against the config file
+type ClassifierConfig struct {
+	Model         string  `yaml:"model"`
+	MinConfidence float64 `yaml:"min_confidence,omitempty"`
+}
+
+// DefaultMinConfidence is used when the classifier section has no min_confidence
+const DefaultMinConfidence = 0.7
+
+// DefaultExpenseCategories are the ezBookkeeping default expense categories
+var DefaultExpenseCategories = []string{
+	"Food & Drink",
+	"Clothing & Appearance",
+	"Housing & Houseware",
+	"Transportation",
+	"Communication",
+	"Entertainment",
+	"Education & Studying",
+	"Medical & Healthcare",
+	"Gift & Social",
+	"Finance & Insurance",
+	"Miscellaneous",
+}
+
+// DefaultIncomeCategories are the ezBookkeeping default income categories
+var DefaultIncomeCategories = []string{
+	"Occupational Earnings",
+	"Finance & Investment",
+	"Miscellaneous",
+}
+
+// DefaultTransferCategories are the ezBookkeeping default transfer categories
+var DefaultTransferCategories = []string{
+	"General Transfer",
+}
+
+// LoadConfig reads and parses the YAML configuration file, including the files it lists under include
 func LoadConfig(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	config, err := loadLayered(path, make(map[string]bool))
 	if err != nil {
 		return nil, err
 	}
-
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := checkRules(config, path); err != nil {
 		return nil, err
 	}
 
-	if config.Categories == nil {
-		config.Categories = make(map[string]*Category)
+	for _, merchant := range config.Merchants {
+		if _, err := merchant.CompilePatterns(); err != nil {
+			return nil, err
+		}
 	}
 
-	return &config, nil
+	return config, nil
 }
 
-// SaveConfig writes the configuration to a YAML file
-func SaveConfig(path string, config *Config) error {
-	data, err := yaml.Marshal(config)
+// parseFile parses a single config file without resolving includes
+// Unknown keys and invalid values are reported with their file:line:column
+func parseFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := decodeStrict(path, data)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if config.Categories == nil {
+		config.Categories = make(map[string]*Category)
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return config, nil
 }
 
 // IsKnownPartner checks if a partner is in the known partners list
+// This scans the list; categorizer.Categorizer keeps an index for repeated lookups
 func (c *Config) IsKnownPartner(partner string) bool {
 	for _, known := range c.KnownPartners {
 		if known == partner {
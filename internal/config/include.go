@@ -0,0 +1,247 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DefaultConfigName is looked up in the working directory when no config path is given
+const DefaultConfigName = "categories.yaml"
+
+// UserConfigPath returns the user-level default config, e.g. ~/.config/ezbook-convert/categories.yaml
+func UserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ezbook-convert", DefaultConfigName), nil
+}
+
+// FindConfig returns the config to use when no path is given:
+// categories.yaml in the working directory, then the user-level config, "" if neither exists
+func FindConfig() string {
+	if _, err := os.Stat(DefaultConfigName); err == nil {
+		return DefaultConfigName
+	}
+	if path, err := UserConfigPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// resolveIncludes loads the files listed in include, relative to dir
+// Later entries take precedence over earlier ones; a directory includes its *.yaml and *.yml files by name
+func resolveIncludes(dir string, includes []string, visiting map[string]bool) (*Config, error) {
+	layered := &Config{Categories: make(map[string]*Category)}
+
+	for _, include := range includes {
+		paths, err := expandInclude(dir, include)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			included, err := loadLayered(path, visiting)
+			if err != nil {
+				return nil, fmt.Errorf("include %s: %w", include, err)
+			}
+			if err := resolveModelPath(included, path); err != nil {
+				return nil, err
+			}
+			layered = layer(layered, included)
+		}
+	}
+
+	return layered, nil
+}
+
+// resolveModelPath makes the model path of an included file absolute, as it is relative to that file
+// The main config keeps its own path as written, so saving doesn't rewrite it
+func resolveModelPath(config *Config, path string) error {
+	if config.Classifier == nil || config.Classifier.Model == "" || filepath.IsAbs(config.Classifier.Model) {
+		return nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	resolved := *config.Classifier
+	resolved.Model = filepath.Join(filepath.Dir(absPath), resolved.Model)
+	config.Classifier = &resolved
+	return nil
+}
+
+// expandInclude turns an include entry into file paths: a file, a directory or a glob pattern
+func expandInclude(dir, include string) ([]string, error) {
+	path := include
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	if strings.ContainsAny(include, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", include, err)
+		}
+		sort.Strings(matches)
+		return matches, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("include %s: %w", include, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("include %s: %w", include, err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		paths = append(paths, filepath.Join(path, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// loadLayered reads a config file and everything it includes, the file itself taking precedence
+func loadLayered(path string, visiting map[string]bool) (*Config, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if visiting[absPath] {
+		return nil, fmt.Errorf("include cycle at %s", path)
+	}
+	visiting[absPath] = true
+	defer delete(visiting, absPath)
+
+	config, err := parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.Include) == 0 {
+		return config, nil
+	}
+
+	included, err := resolveIncludes(filepath.Dir(path), config.Include, visiting)
+	if err != nil {
+		return nil, err
+	}
+	return layer(included, config), nil
+}
+
+// layer combines a lower and a higher precedence config
+// Lists are united; subcategories, fuzzy, classifier and type rule settings of the higher one win
+func layer(lower, higher *Config) *Config {
+	result, _ := Merge(higher, lower, false)
+
+	if result.Fuzzy == nil {
+		result.Fuzzy = lower.Fuzzy
+	}
+	if result.Classifier == nil {
+		result.Classifier = lower.Classifier
+	}
+	if result.TypeRules == nil {
+		result.TypeRules = lower.TypeRules
+	}
+	result.Owners, _ = mergeList("owners", higher.Owners, lower.Owners, false, strings.EqualFold)
+	result.Include = higher.Include
+
+	result.ruleless = make(map[string]*ValidationError)
+	for _, c := range []*Config{lower, higher} {
+		for name, err := range c.ruleless {
+			result.ruleless[name] = err
+		}
+	}
+
+	return result
+}
+
+// subtractIncluded removes everything provided by the included files,
+// so saving a layered config doesn't copy shared rules into the main file
+func subtractIncluded(config, included *Config) *Config {
+	result := *config
+
+	result.KnownPartners = subtractList(config.KnownPartners, included.KnownPartners, exactEqual)
+	result.Owners = subtractList(config.Owners, included.Owners, strings.EqualFold)
+
+	if included.Fuzzy != nil && config.Fuzzy != nil && *included.Fuzzy == *config.Fuzzy {
+		result.Fuzzy = nil
+	}
+	if included.Classifier != nil && config.Classifier != nil && *included.Classifier == *config.Classifier {
+		result.Classifier = nil
+	}
+	if included.TypeRules != nil && reflect.DeepEqual(included.TypeRules, config.TypeRules) {
+		result.TypeRules = nil
+	}
+
+	result.Categories = make(map[string]*Category)
+	for name, category := range config.Categories {
+		other := included.Categories[name]
+		if other == nil || category == nil {
+			result.Categories[name] = category
+			continue
+		}
+
+		remaining := &Category{
+			Keywords:     subtractList(category.Keywords, other.Keywords, strings.EqualFold),
+			ExactMatches: subtractList(category.ExactMatches, other.ExactMatches, exactEqual),
+		}
+		if category.SubCategory != other.SubCategory {
+			remaining.SubCategory = category.SubCategory
+		}
+		if remaining.SubCategory == "" && len(remaining.Keywords) == 0 && len(remaining.ExactMatches) == 0 {
+			continue
+		}
+		result.Categories[name] = remaining
+	}
+
+	includedMerchants := make(map[string]*Merchant)
+	for _, m := range included.Merchants {
+		includedMerchants[m.Name] = m
+	}
+	result.Merchants = nil
+	for _, m := range config.Merchants {
+		other, ok := includedMerchants[m.Name]
+		if !ok {
+			result.Merchants = append(result.Merchants, m)
+			continue
+		}
+		if patterns := subtractList(m.Patterns, other.Patterns, exactEqual); len(patterns) > 0 {
+			result.Merchants = append(result.Merchants, &Merchant{Name: m.Name, Patterns: patterns})
+		}
+	}
+
+	return &result
+}
+
+func subtractList(list, remove []string, equal func(a, b string) bool) []string {
+	var result []string
+	for _, item := range list {
+		found := false
+		for _, other := range remove {
+			if equal(item, other) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadConfigIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"categories.yaml": `include:
+  - shared
+known_partners:
+  - LIDL
+categories:
+  Food & Drink:
+    subcategory: Groceries
+    keywords:
+      - lidl
+`,
+		"shared/10-food.yaml": `known_partners:
+  - TESCO
+owners:
+  - Kovács Béla
+categories:
+  Food & Drink:
+    subcategory: Food
+    keywords:
+      - tesco
+classifier:
+  model: model.json
+`,
+		"shared/20-fuel.yml": `categories:
+  Transportation:
+    subcategory: Fuel
+    keywords:
+      - mol
+`,
+		"shared/notes.txt": "not a config",
+	})
+
+	cfg, err := LoadConfig(filepath.Join(dir, "categories.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"LIDL", "TESCO"}; !reflect.DeepEqual(cfg.KnownPartners, want) {
+		t.Errorf("known_partners = %q, want %q", cfg.KnownPartners, want)
+	}
+	food := cfg.Categories["Food & Drink"]
+	if food.SubCategory != "Groceries" || !reflect.DeepEqual(food.Keywords, []string{"lidl", "tesco"}) {
+		t.Errorf("Food & Drink = %+v, want the main file's subcategory and both keywords", food)
+	}
+	if cfg.Categories["Transportation"] == nil {
+		t.Error("Transportation from the .yml fragment is missing")
+	}
+	if !reflect.DeepEqual(cfg.Owners, []string{"Kovács Béla"}) {
+		t.Errorf("owners = %q", cfg.Owners)
+	}
+	// A relative model path in a fragment is relative to the fragment
+	if want := filepath.Join(dir, "shared", "model.json"); cfg.Classifier == nil || cfg.Classifier.Model != want {
+		t.Errorf("classifier = %+v, want model %s", cfg.Classifier, want)
+	}
+}
+
+func TestSaveConfigKeepsIncludedRulesOut(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "categories.yaml")
+	writeFiles(t, dir, map[string]string{
+		"categories.yaml": `include:
+  - shared.yaml
+classifier:
+  model: models/local.json
+categories:
+  Food & Drink:
+    keywords:
+      - lidl
+`,
+		"shared.yaml": `known_partners:
+  - TESCO
+categories:
+  Food & Drink:
+    subcategory: Groceries
+    keywords:
+      - tesco
+`,
+	})
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The main file's model path is kept as written, callers resolve it against the config file
+	if cfg.Classifier.Model != "models/local.json" {
+		t.Errorf("classifier model = %q, want it unchanged", cfg.Classifier.Model)
+	}
+
+	cfg.AddKnownPartner("LIDL")
+	if _, err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(data)
+	for _, want := range []string{"model: models/local.json", "- lidl", "- LIDL"} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved config does not contain %q:\n%s", want, saved)
+		}
+	}
+	for _, unwanted := range []string{"TESCO", "tesco", "Groceries", dir} {
+		if strings.Contains(saved, unwanted) {
+			t.Errorf("saved config contains %q from the included file:\n%s", unwanted, saved)
+		}
+	}
+}
+
+func TestSaveConfigKeepsIncludedModelOut(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "categories.yaml")
+	writeFiles(t, dir, map[string]string{
+		"categories.yaml":  "include:\n  - shared/base.yaml\nknown_partners:\n  - LIDL\n",
+		"shared/base.yaml": "classifier:\n  model: model.json\n",
+	})
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "classifier") {
+		t.Errorf("the included classifier was copied into the main file:\n%s", data)
+	}
+}
+
+func TestLoadConfigIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": "include:\n  - b.yaml\n",
+		"b.yaml": "include:\n  - a.yaml\n",
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "a.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("error = %v, want an include cycle", err)
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("LoadConfig of a missing file should fail")
+	}
+}
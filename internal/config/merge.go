@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Change is a single difference applied (or withheld) while merging configs
+type Change struct {
+	Op    string // "add", "remove", "keep" (missing from incoming but removals not allowed)
+	Path  string
+	Value string
+}
+
+func (c Change) String() string {
+	prefix := map[string]string{"add": "+", "remove": "-", "keep": "~"}[c.Op]
+	if c.Value == "" {
+		return fmt.Sprintf("%s %s", prefix, c.Path)
+	}
+	return fmt.Sprintf("%s %s: %s", prefix, c.Path, c.Value)
+}
+
+// Merge combines an incoming config (e.g. an LLM response) into the base config
+// Known partners, keywords, exact matches and merchants are united; anything missing from
+// incoming is only deleted when allowRemovals is set. Fuzzy, classifier, owner, include and type rule settings are kept from base.
+func Merge(base, incoming *Config, allowRemovals bool) (*Config, []Change) {
+	merged := &Config{
+		Version:    base.Version,
+		Include:    base.Include,
+		Fuzzy:      base.Fuzzy,
+		Classifier: base.Classifier,
+		Owners:     base.Owners,
+		TypeRules:  base.TypeRules,
+		Categories: make(map[string]*Category),
+	}
+	var changes []Change
+
+	var partnerChanges []Change
+	merged.KnownPartners, partnerChanges = mergeList("known_partners", base.KnownPartners, incoming.KnownPartners, allowRemovals, exactEqual)
+	changes = append(changes, partnerChanges...)
+
+	for _, name := range unionKeys(base.Categories, incoming.Categories) {
+		path := "categories." + name
+		baseCategory, inBase := base.Categories[name]
+		incomingCategory, inIncoming := incoming.Categories[name]
+
+		switch {
+		case inBase && !inIncoming:
+			if allowRemovals {
+				changes = append(changes, Change{"remove", path, ""})
+				continue
+			}
+			changes = append(changes, Change{"keep", path, ""})
+			merged.Categories[name] = baseCategory
+
+		case !inBase && inIncoming:
+			changes = append(changes, Change{"add", path, ""})
+			merged.Categories[name] = incomingCategory
+
+		default:
+			category, categoryChanges := mergeCategory(path, baseCategory, incomingCategory, allowRemovals)
+			// A category whose last rule is removed would never match, so it goes as a whole
+			if allowRemovals && len(category.Keywords) == 0 && len(category.ExactMatches) == 0 {
+				changes = append(changes, Change{"remove", path, ""})
+				continue
+			}
+			merged.Categories[name] = category
+			changes = append(changes, categoryChanges...)
+		}
+	}
+
+	merged.Merchants, changes = mergeMerchants(base.Merchants, incoming.Merchants, allowRemovals, changes)
+
+	return merged, changes
+}
+
+func mergeCategory(path string, base, incoming *Category, allowRemovals bool) (*Category, []Change) {
+	if base == nil {
+		base = &Category{}
+	}
+	if incoming == nil {
+		incoming = &Category{}
+	}
+
+	var changes []Change
+	category := &Category{SubCategory: base.SubCategory}
+
+	if incoming.SubCategory != "" && incoming.SubCategory != base.SubCategory {
+		if allowRemovals || base.SubCategory == "" {
+			category.SubCategory = incoming.SubCategory
+			changes = append(changes, Change{"add", path + ".subcategory", incoming.SubCategory})
+			if base.SubCategory != "" {
+				changes = append(changes, Change{"remove", path + ".subcategory", base.SubCategory})
+			}
+		} else {
+			changes = append(changes, Change{"keep", path + ".subcategory", base.SubCategory})
+		}
+	}
+
+	var listChanges []Change
+	category.Keywords, listChanges = mergeList(path+".keywords", base.Keywords, incoming.Keywords, allowRemovals, strings.EqualFold)
+	changes = append(changes, listChanges...)
+
+	category.ExactMatches, listChanges = mergeList(path+".exact_matches", base.ExactMatches, incoming.ExactMatches, allowRemovals, exactEqual)
+	changes = append(changes, listChanges...)
+
+	return category, changes
+}
+
+func mergeMerchants(base, incoming []*Merchant, allowRemovals bool, changes []Change) ([]*Merchant, []Change) {
+	incomingByName := make(map[string]*Merchant)
+	for _, m := range incoming {
+		incomingByName[m.Name] = m
+	}
+
+	var merged []*Merchant
+	seen := make(map[string]bool)
+
+	for _, m := range base {
+		seen[m.Name] = true
+		path := "merchants." + m.Name
+		other, ok := incomingByName[m.Name]
+		if !ok {
+			if allowRemovals {
+				changes = append(changes, Change{"remove", path, ""})
+				continue
+			}
+			changes = append(changes, Change{"keep", path, ""})
+			merged = append(merged, m)
+			continue
+		}
+
+		patterns, patternChanges := mergeList(path+".patterns", m.Patterns, other.Patterns, allowRemovals, exactEqual)
+		merged = append(merged, &Merchant{Name: m.Name, Patterns: patterns})
+		changes = append(changes, patternChanges...)
+	}
+
+	for _, m := range incoming {
+		if seen[m.Name] {
+			continue
+		}
+		seen[m.Name] = true
+		merged = append(merged, m)
+		changes = append(changes, Change{"add", "merchants." + m.Name, ""})
+	}
+
+	return merged, changes
+}
+
+// mergeList unites two lists keeping base order, appending new items from incoming
+func mergeList(path string, base, incoming []string, allowRemovals bool, equal func(a, b string) bool) ([]string, []Change) {
+	var merged []string
+	var changes []Change
+
+	contains := func(list []string, value string) bool {
+		for _, item := range list {
+			if equal(item, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, item := range base {
+		if contains(incoming, item) {
+			merged = append(merged, item)
+			continue
+		}
+		if allowRemovals {
+			changes = append(changes, Change{"remove", path, item})
+			continue
+		}
+		changes = append(changes, Change{"keep", path, item})
+		merged = append(merged, item)
+	}
+
+	for _, item := range incoming {
+		if strings.TrimSpace(item) == "" || contains(merged, item) {
+			continue
+		}
+		merged = append(merged, item)
+		changes = append(changes, Change{"add", path, item})
+	}
+
+	return merged, changes
+}
+
+func unionKeys(a, b map[string]*Category) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]*Category{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func exactEqual(a, b string) bool {
+	return a == b
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func mergeBase() *Config {
+	cfg := New()
+	cfg.Include = []string{"shared.yaml"}
+	cfg.Owners = []string{"Kovács Béla"}
+	cfg.Fuzzy = &FuzzyConfig{Threshold: 0.9}
+	cfg.Classifier = &ClassifierConfig{Model: "model.json"}
+	cfg.KnownPartners = []string{"TESCO", "MOL"}
+	cfg.Categories["Food & Drink"] = &Category{SubCategory: "Groceries", Keywords: []string{"tesco"}}
+	cfg.Categories["Transportation"] = &Category{SubCategory: "Fuel", Keywords: []string{"mol"}}
+	cfg.Merchants = []*Merchant{{Name: "Tesco", Patterns: []string{"^tesco"}}}
+	return cfg
+}
+
+func mergeIncoming() *Config {
+	return &Config{
+		KnownPartners: []string{"TESCO", "SPAR"},
+		Categories: map[string]*Category{
+			"Food & Drink":  {SubCategory: "Restaurants", Keywords: []string{"TESCO", "spar"}, ExactMatches: []string{"SPAR"}},
+			"Entertainment": {SubCategory: "Streaming", Keywords: []string{"netflix"}},
+		},
+		Merchants: []*Merchant{{Name: "Spar", Patterns: []string{"^spar"}}},
+	}
+}
+
+func changeStrings(changes []Change) []string {
+	var lines []string
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	return lines
+}
+
+func TestMerge(t *testing.T) {
+	base := mergeBase()
+	merged, changes := Merge(base, mergeIncoming(), false)
+
+	want := []string{
+		"~ known_partners: MOL",
+		"+ known_partners: SPAR",
+		"+ categories.Entertainment",
+		"~ categories.Food & Drink.subcategory: Groceries",
+		"+ categories.Food & Drink.keywords: spar",
+		"+ categories.Food & Drink.exact_matches: SPAR",
+		"~ categories.Transportation",
+		"~ merchants.Tesco",
+		"+ merchants.Spar",
+	}
+	if got := changeStrings(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes =\n%q\nwant\n%q", got, want)
+	}
+
+	if want := []string{"TESCO", "MOL", "SPAR"}; !reflect.DeepEqual(merged.KnownPartners, want) {
+		t.Errorf("known_partners = %q, want %q", merged.KnownPartners, want)
+	}
+	// Keywords compare case-insensitively, the base spelling is kept
+	if want := []string{"tesco", "spar"}; !reflect.DeepEqual(merged.Categories["Food & Drink"].Keywords, want) {
+		t.Errorf("keywords = %q, want %q", merged.Categories["Food & Drink"].Keywords, want)
+	}
+	if merged.Categories["Food & Drink"].SubCategory != "Groceries" {
+		t.Errorf("subcategory = %q, want the base one without removals", merged.Categories["Food & Drink"].SubCategory)
+	}
+
+	// Settings that an LLM response doesn't carry come from base
+	if !reflect.DeepEqual(merged.Include, base.Include) || !reflect.DeepEqual(merged.Owners, base.Owners) ||
+		merged.Fuzzy != base.Fuzzy || merged.Classifier != base.Classifier ||
+		merged.Version != base.Version || !reflect.DeepEqual(merged.TypeRules, base.TypeRules) {
+		t.Errorf("merged settings = %+v, want those of base", merged)
+	}
+}
+
+func TestMergeAllowRemovals(t *testing.T) {
+	merged, changes := Merge(mergeBase(), mergeIncoming(), true)
+
+	want := []string{
+		"- known_partners: MOL",
+		"+ known_partners: SPAR",
+		"+ categories.Entertainment",
+		"+ categories.Food & Drink.subcategory: Restaurants",
+		"- categories.Food & Drink.subcategory: Groceries",
+		"+ categories.Food & Drink.keywords: spar",
+		"+ categories.Food & Drink.exact_matches: SPAR",
+		"- categories.Transportation",
+		"- merchants.Tesco",
+		"+ merchants.Spar",
+	}
+	if got := changeStrings(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes =\n%q\nwant\n%q", got, want)
+	}
+	if _, ok := merged.Categories["Transportation"]; ok {
+		t.Error("Transportation should have been removed")
+	}
+}
+
+func TestMergeNullCategory(t *testing.T) {
+	base := mergeBase()
+	base.Categories["Empty"] = nil
+	incoming := &Config{Categories: map[string]*Category{"Empty": {Keywords: []string{"aldi"}}}}
+
+	merged, _ := Merge(base, incoming, false)
+	if category := merged.Categories["Empty"]; category == nil || !reflect.DeepEqual(category.Keywords, []string{"aldi"}) {
+		t.Errorf("Empty = %+v, want keyword aldi", category)
+	}
+}
+
+func TestMergeRemovesCategoriesWithoutRules(t *testing.T) {
+	base := mergeBase()
+	incoming := &Config{Categories: map[string]*Category{
+		"Food & Drink":   {SubCategory: "Groceries"},
+		"Transportation": {SubCategory: "Fuel", Keywords: []string{"mol"}},
+	}}
+
+	merged, changes := Merge(base, incoming, true)
+	if _, ok := merged.Categories["Food & Drink"]; ok {
+		t.Error("Food & Drink has no rules left and should have been removed")
+	}
+	want := []string{"- categories.Food & Drink", "- known_partners: TESCO", "- known_partners: MOL", "- merchants.Tesco"}
+	got := changeStrings(changes)
+	for _, change := range want {
+		if !contains(got, change) {
+			t.Errorf("changes %q do not include %q", got, change)
+		}
+	}
+}
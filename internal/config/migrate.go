@@ -0,0 +1,123 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config layout written by this version of ezbook-convert
+// Files without a version field are version 1
+const CurrentVersion = 2
+
+// migration upgrades a config document from the previous version to version
+type migration struct {
+	version     int
+	description string
+	apply       func(root *yaml.Node)
+}
+
+var migrations = []migration{
+	{2, "move the built-in transaction type fallbacks into type_rules", addDefaultTypeRules},
+}
+
+// Migrate upgrades a config file to CurrentVersion, keeping comments and key order
+// Returns the new file contents, the version the file had and a description of every step applied
+func Migrate(file string, data []byte) ([]byte, int, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, nil, syntaxError(file, err)
+	}
+	if len(doc.Content) == 0 {
+		return data, CurrentVersion, nil, nil
+	}
+
+	from, steps, err := migrateNode(file, doc.Content[0])
+	if err != nil || len(steps) == 0 {
+		return data, from, steps, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(detectIndent(data))
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, from, steps, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, from, steps, err
+	}
+	return buf.Bytes(), from, steps, nil
+}
+
+// migrateNode upgrades the root mapping of a config document in place
+func migrateNode(file string, root *yaml.Node) (int, []string, error) {
+	if root.Kind != yaml.MappingNode {
+		return CurrentVersion, nil, nil
+	}
+
+	version := 1
+	versionNode := mappingValue(root, "version")
+	if versionNode != nil {
+		parsed, err := strconv.Atoi(versionNode.Value)
+		if versionNode.Kind != yaml.ScalarNode || err != nil || parsed < 1 {
+			return 0, nil, &ValidationError{File: file, Line: versionNode.Line, Column: versionNode.Column,
+				Message: fmt.Sprintf("version must be a positive number, got %q", versionNode.Value)}
+		}
+		version = parsed
+	}
+	if version > CurrentVersion {
+		return version, nil, &ValidationError{File: file, Line: versionNode.Line, Column: versionNode.Column,
+			Message: fmt.Sprintf("config version %d is newer than this ezbook-convert supports (%d), please upgrade", version, CurrentVersion)}
+	}
+
+	var steps []string
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		m.apply(root)
+		steps = append(steps, fmt.Sprintf("version %d: %s", m.version, m.description))
+	}
+	if len(steps) == 0 {
+		return version, nil, nil
+	}
+
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}
+	if versionNode != nil {
+		versionNode.Value = value.Value
+	} else {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+	}
+	return version, steps, nil
+}
+
+// addDefaultTypeRules writes the type fallbacks that version 1 had built in
+func addDefaultTypeRules(root *yaml.Node) {
+	if mappingValue(root, "type_rules") != nil {
+		return
+	}
+
+	var rules yaml.Node
+	if err := rules.Encode(DefaultTypeRules); err != nil {
+		return
+	}
+	key := &yaml.Node{
+		Kind:        yaml.ScalarNode,
+		Tag:         "!!str",
+		Value:       "type_rules",
+		HeadComment: "# Fallbacks by K&H transaction type, used when no other rule matches",
+	}
+	root.Content = append(root.Content, key, &rules)
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const versionOneConfig = `# Categorization rules
+known_partners:
+  - TESCO # the supermarket
+categories:
+  # Everyday shopping
+  Food & Drink:
+    subcategory: Groceries
+    keywords:
+      - tesco
+`
+
+func TestMigrate(t *testing.T) {
+	migrated, from, steps, err := Migrate("categories.yaml", []byte(versionOneConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 1 {
+		t.Errorf("from = %d, want 1", from)
+	}
+	if len(steps) != 1 || !strings.HasPrefix(steps[0], "version 2: ") {
+		t.Errorf("steps = %q, want the version 2 step", steps)
+	}
+
+	text := string(migrated)
+	for _, want := range []string{"# Categorization rules", "- TESCO # the supermarket", "# Everyday shopping", "type_rules:"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("migrated config does not contain %q:\n%s", want, text)
+		}
+	}
+	// The comment belongs to known_partners, so version goes above it
+	if !strings.HasPrefix(text, "version: 2\n# Categorization rules\nknown_partners:") {
+		t.Errorf("version should be the first field:\n%s", text)
+	}
+
+	cfg, err := decodeStrict("categories.yaml", migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != CurrentVersion || len(cfg.TypeRules) != len(DefaultTypeRules) {
+		t.Errorf("migrated config is version %d with %d type rules", cfg.Version, len(cfg.TypeRules))
+	}
+
+	// Migrating again changes nothing
+	again, from, steps, err := Migrate("categories.yaml", migrated)
+	if err != nil || from != CurrentVersion || len(steps) != 0 || string(again) != text {
+		t.Errorf("second Migrate = %d, %q, %v, want no changes", from, steps, err)
+	}
+}
+
+func TestMigrateKeepsTypeRules(t *testing.T) {
+	data := "type_rules:\n  - patterns: [átutalás]\n    category: Miscellaneous\n    subcategory: Other Expense\n"
+	migrated, _, _, err := Migrate("categories.yaml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := decodeStrict("categories.yaml", migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.TypeRules) != 1 || cfg.TypeRules[0].Patterns[0] != "átutalás" {
+		t.Errorf("type_rules = %+v, want the existing rule only", cfg.TypeRules)
+	}
+}
+
+func TestMigrateVersionErrors(t *testing.T) {
+	tests := []struct {
+		data, want string
+	}{
+		{"version: 3\n", "categories.yaml:1:10: config version 3 is newer than this ezbook-convert supports (2), please upgrade"},
+		{"version: two\n", `categories.yaml:1:10: version must be a positive number, got "two"`},
+		{"version: 0\n", `categories.yaml:1:10: version must be a positive number, got "0"`},
+	}
+	for _, tt := range tests {
+		_, _, _, err := Migrate("categories.yaml", []byte(tt.data))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Migrate(%q) error = %v, want %q", tt.data, err, tt.want)
+		}
+		// Loading reports the same error
+		if _, err := decodeStrict("categories.yaml", []byte(tt.data)); err == nil || err.Error() != tt.want {
+			t.Errorf("decodeStrict(%q) error = %v, want %q", tt.data, err, tt.want)
+		}
+	}
+}
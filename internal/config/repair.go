@@ -0,0 +1,220 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Top-level keys that mark the start of the YAML document in an LLM response
+	topLevelKeyPattern = regexp.MustCompile(`^(version|include|known_partners|categories|fuzzy|merchants|classifier|owners|type_rules)\s*:`)
+
+	// Placeholder names like TRANSFER_PARTNER that YAML parsed as a flow sequence
+	placeholderPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+	typographicQuotes = strings.NewReplacer(
+		"\u201c", `"`, "\u201d", `"`, "\u201e", `"`, "\u201f", `"`,
+		"\u2018", "'", "\u2019", "'", "\u201a", "'", "\u201b", "'",
+	)
+
+	invisibleCharacters = strings.NewReplacer(
+		"\u00ad", "", // Soft hyphen
+		"\u200b", "", // Zero-width space
+		"\u200c", "", // Zero-width non-joiner
+		"\u200d", "", // Zero-width joiner
+		"\ufeff", "", // Byte order mark
+		"\u00a0", " ", // Non-breaking space
+	)
+)
+
+// LoadConfigTolerant reads a config file, repairing common LLM formatting mistakes
+// Returns the fixes that were applied so they can be reported to the user
+func LoadConfigTolerant(path string) (*Config, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return Repair(data)
+}
+
+// Repair parses YAML generated by an LLM, fixing code fences, surrounding prose,
+// typographic quotes, invisible characters and nested lists
+func Repair(data []byte) (*Config, []string, error) {
+	text, fixes := repairText(string(data))
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &root); err != nil {
+		return nil, fixes, fmt.Errorf("invalid YAML after repair: %w", err)
+	}
+
+	fixes = append(fixes, repairNode(&root, "")...)
+
+	if len(root.Content) > 0 {
+		if _, _, err := migrateNode("", root.Content[0]); err != nil {
+			return nil, fixes, err
+		}
+	}
+
+	var config Config
+	if err := root.Decode(&config); err != nil {
+		return nil, fixes, err
+	}
+
+	if config.Categories == nil {
+		config.Categories = make(map[string]*Category)
+	}
+
+	for _, merchant := range config.Merchants {
+		if _, err := merchant.CompilePatterns(); err != nil {
+			return nil, fixes, err
+		}
+	}
+
+	return &config, fixes, nil
+}
+
+// repairText fixes problems that prevent the text from being parsed as YAML
+func repairText(text string) (string, []string) {
+	var fixes []string
+
+	if block, ok := extractCodeBlock(text); ok {
+		text = block
+		fixes = append(fixes, "extracted YAML from code block")
+	}
+
+	lines := strings.Split(text, "\n")
+
+	// Drop prose before the first top-level config key
+	start := 0
+	for start < len(lines) && !topLevelKeyPattern.MatchString(invisibleCharacters.Replace(lines[start])) {
+		start++
+	}
+	if start == len(lines) {
+		start = 0 // No known key found, leave the text for the YAML parser to report
+	} else if start > 0 {
+		fixes = append(fixes, fmt.Sprintf("removed %d line(s) of text before the YAML", start))
+	}
+
+	// Drop prose after the YAML: unindented lines that are neither keys nor comments
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") ||
+			strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") || topLevelKeyPattern.MatchString(line) {
+			continue
+		}
+		end = i
+		break
+	}
+	if end < len(lines) {
+		fixes = append(fixes, fmt.Sprintf("removed %d line(s) of text after the YAML", len(lines)-end))
+	}
+	text = strings.Join(lines[start:end], "\n")
+
+	if count := countReplacements(typographicQuotes, text); count > 0 {
+		text = typographicQuotes.Replace(text)
+		fixes = append(fixes, fmt.Sprintf("normalized %d typographic quote(s)", count))
+	}
+
+	if count := countReplacements(invisibleCharacters, text); count > 0 {
+		text = invisibleCharacters.Replace(text)
+		fixes = append(fixes, fmt.Sprintf("removed %d invisible character(s)", count))
+	}
+
+	return text, fixes
+}
+
+// extractCodeBlock returns the content of the first ``` fenced block
+func extractCodeBlock(text string) (string, bool) {
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return "", false
+	}
+
+	// Skip the language tag on the opening fence
+	rest := text[start+3:]
+	newline := strings.Index(rest, "\n")
+	if newline < 0 {
+		return "", false
+	}
+	rest = rest[newline+1:]
+
+	if end := strings.Index(rest, "```"); end >= 0 {
+		rest = rest[:end]
+	}
+	return rest, true
+}
+
+// repairNode flattens nested sequences and restores placeholders parsed as flow sequences
+func repairNode(node *yaml.Node, path string) []string {
+	var fixes []string
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			fixes = append(fixes, repairNode(child, path)...)
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := node.Content[i].Value
+			if path != "" {
+				childPath = path + "." + childPath
+			}
+			fixes = append(fixes, repairNode(node.Content[i+1], childPath)...)
+		}
+
+	case yaml.SequenceNode:
+		var items []*yaml.Node
+		for _, item := range node.Content {
+			if item.Kind != yaml.SequenceNode {
+				items = append(items, item)
+				continue
+			}
+
+			// "- [TRANSFER_PARTNER]" is a placeholder, not a list
+			if len(item.Content) == 1 && item.Content[0].Kind == yaml.ScalarNode &&
+				placeholderPattern.MatchString(item.Content[0].Value) {
+				placeholder := "[" + item.Content[0].Value + "]"
+				items = append(items, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: placeholder})
+				fixes = append(fixes, fmt.Sprintf("%s: restored placeholder %s (line %d)", path, placeholder, item.Line))
+				continue
+			}
+
+			flattened := flattenSequence(item)
+			items = append(items, flattened...)
+			fixes = append(fixes, fmt.Sprintf("%s: flattened nested list of %d item(s) (line %d)", path, len(flattened), item.Line))
+		}
+		node.Content = items
+	}
+
+	return fixes
+}
+
+func flattenSequence(node *yaml.Node) []*yaml.Node {
+	var items []*yaml.Node
+	for _, item := range node.Content {
+		if item.Kind == yaml.SequenceNode {
+			items = append(items, flattenSequence(item)...)
+		} else {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// countReplacements counts how many characters the replacer would change
+func countReplacements(replacer *strings.Replacer, text string) int {
+	count := 0
+	for _, r := range text {
+		if replacer.Replace(string(r)) != string(r) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepairBrokenExample(t *testing.T) {
+	data, err := os.ReadFile("../../categories_broken.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, fixes, err := Repair(data)
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+
+	wantFixes := []string{
+		"normalized 12 typographic quote(s)",
+		"removed 1 invisible character(s)",
+		"known_partners: restored placeholder [TRANSFER_PARTNER] (line 7)",
+		"categories.Food & Drink.keywords: flattened nested list of 6 item(s) (line 114)",
+		"categories.Miscellaneous.keywords: restored placeholder [TRANSFER_PARTNER] (line 191)",
+	}
+	if !reflect.DeepEqual(fixes, wantFixes) {
+		t.Errorf("fixes =\n%q\nwant\n%q", fixes, wantFixes)
+	}
+	if cfg.KnownPartners[5] != "[TRANSFER_PARTNER]" {
+		t.Errorf("known_partners[5] = %q, want [TRANSFER_PARTNER]", cfg.KnownPartners[5])
+	}
+	if !contains(cfg.Categories["Miscellaneous"].Keywords, "[TRANSFER_PARTNER]") {
+		t.Errorf("Miscellaneous keywords = %q, want [TRANSFER_PARTNER]", cfg.Categories["Miscellaneous"].Keywords)
+	}
+	if cfg.Version != CurrentVersion || len(cfg.TypeRules) != len(DefaultTypeRules) {
+		t.Errorf("repaired config is version %d with %d type rules, want it migrated", cfg.Version, len(cfg.TypeRules))
+	}
+}
+
+func TestRepairLLMFormatting(t *testing.T) {
+	response := "Here is the updated config:\n\n" +
+		"```yaml\n" +
+		"known_partners:\n" +
+		"  - „TESCO”\n" +
+		"  - SP­AR\n" +
+		"categories:\n" +
+		"  Food & Drink:\n" +
+		"    subcategory: Groceries\n" +
+		"    keywords:\n" +
+		"      - [tesco, spar]\n" +
+		"```\n" +
+		"Let me know if you need anything else."
+
+	cfg, fixes, err := Repair([]byte(response))
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+
+	for _, want := range []string{"extracted YAML from code block", "typographic quote", "invisible character", "flattened nested list of 2 item(s)"} {
+		found := false
+		for _, fix := range fixes {
+			found = found || strings.Contains(fix, want)
+		}
+		if !found {
+			t.Errorf("fixes %q do not mention %q", fixes, want)
+		}
+	}
+	if want := []string{"TESCO", "SPAR"}; !reflect.DeepEqual(cfg.KnownPartners, want) {
+		t.Errorf("known_partners = %q, want %q", cfg.KnownPartners, want)
+	}
+	if want := []string{"tesco", "spar"}; !reflect.DeepEqual(cfg.Categories["Food & Drink"].Keywords, want) {
+		t.Errorf("keywords = %q, want %q", cfg.Categories["Food & Drink"].Keywords, want)
+	}
+}
+
+func TestRepairProse(t *testing.T) {
+	response := "Sure!\nknown_partners:\n  - TESCO\ncategories: {}\nThat's all."
+
+	cfg, fixes, err := Repair([]byte(response))
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	want := []string{"removed 1 line(s) of text before the YAML", "removed 1 line(s) of text after the YAML"}
+	if !reflect.DeepEqual(fixes, want) {
+		t.Errorf("fixes = %q, want %q", fixes, want)
+	}
+	if len(cfg.KnownPartners) != 1 || cfg.Categories == nil {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	if _, _, err := Repair([]byte("categories: [")); err == nil {
+		t.Error("Repair of unparseable YAML should fail")
+	}
+}
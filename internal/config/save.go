@@ -0,0 +1,263 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultIndent is used for new config files
+const DefaultIndent = 4
+
+// SaveConfig writes the configuration to a YAML file
+// An existing file is edited in place through yaml.Node, so comments, key order and
+// quoting survive; the previous version is kept as <path>.bak and the write is atomic
+// Rules that come from included files are not written to the main file
+// An existing file in an older layout is migrated the way migrate-config does it;
+// the steps applied are returned so the caller can tell the user
+func SaveConfig(path string, config *Config) ([]string, error) {
+	if len(config.Include) > 0 {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		included, err := resolveIncludes(filepath.Dir(path), config.Include, map[string]bool{absPath: true})
+		if err != nil {
+			return nil, err
+		}
+		config = subtractIncluded(config, included)
+	}
+
+	var fresh yaml.Node
+	if err := fresh.Encode(config); err != nil {
+		return nil, err
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&fresh}}
+	indent := DefaultIndent
+
+	// A file that doesn't parse (e.g. a broken LLM response being repaired) is replaced
+	var old yaml.Node
+	var steps []string
+	if len(existing) > 0 && yaml.Unmarshal(existing, &old) == nil && len(old.Content) == 1 && old.Content[0].Kind == yaml.MappingNode {
+		// Migrate first, so the version key and new sections end up where migrate-config puts them
+		if _, steps, err = migrateNode(path, old.Content[0]); err != nil {
+			return nil, err
+		}
+		updateNode(old.Content[0], &fresh)
+		doc = &old
+		indent = detectIndent(existing)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return steps, writeWithBackup(path, existing, buf.Bytes())
+}
+
+// WriteFile replaces a config file atomically, keeping the previous version as <path>.bak
+func WriteFile(path string, data []byte) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return writeWithBackup(path, existing, data)
+}
+
+// writeWithBackup keeps the previous contents as <path>.bak and replaces the file atomically
+// Both get the mode of the existing file, so a private config doesn't leave a readable backup
+func writeWithBackup(path string, existing, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	if existing != nil {
+		if err := writeFileAtomic(path+".bak", existing, perm); err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(path, data, perm)
+}
+
+// updateNode changes old in place to hold the values of fresh, keeping comments,
+// key order and styles of everything that still exists
+func updateNode(old, fresh *yaml.Node) {
+	if old.Kind != fresh.Kind {
+		replaceNode(old, fresh)
+		return
+	}
+
+	switch fresh.Kind {
+	case yaml.MappingNode:
+		updateMapping(old, fresh)
+	case yaml.SequenceNode:
+		updateSequence(old, fresh)
+	case yaml.ScalarNode:
+		if old.Value != fresh.Value || old.Tag != fresh.Tag {
+			style := old.Style
+			old.Value, old.Tag = fresh.Value, fresh.Tag
+			// Keep quoting unless the new value needs a different one
+			old.Style = fresh.Style
+			if style == yaml.DoubleQuotedStyle || style == yaml.SingleQuotedStyle {
+				old.Style = style
+			}
+		}
+	}
+}
+
+func updateMapping(old, fresh *yaml.Node) {
+	freshValues := make(map[string]*yaml.Node)
+	var freshKeys []string
+	for i := 0; i+1 < len(fresh.Content); i += 2 {
+		freshKeys = append(freshKeys, fresh.Content[i].Value)
+		freshValues[fresh.Content[i].Value] = fresh.Content[i+1]
+	}
+
+	// Update or drop existing keys in their original order
+	var content []*yaml.Node
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(old.Content); i += 2 {
+		key, value := old.Content[i], old.Content[i+1]
+		freshValue, ok := freshValues[key.Value]
+		if !ok {
+			continue
+		}
+		seen[key.Value] = true
+		updateNode(value, freshValue)
+		content = append(content, key, value)
+	}
+
+	// Append new keys, skipping empty lists and maps the file never had
+	for _, key := range freshKeys {
+		if seen[key] {
+			continue
+		}
+		value := freshValues[key]
+		if isEmptyNode(value) {
+			continue
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+
+	old.Content = content
+}
+
+func updateSequence(old, fresh *yaml.Node) {
+	// Reuse existing items so their comments survive; merchants are matched by name
+	identity := func(n *yaml.Node) string {
+		switch n.Kind {
+		case yaml.ScalarNode:
+			return "=" + n.Value
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == "name" {
+					return "name=" + n.Content[i+1].Value
+				}
+			}
+		}
+		return ""
+	}
+
+	available := make(map[string][]*yaml.Node)
+	for _, item := range old.Content {
+		if id := identity(item); id != "" {
+			available[id] = append(available[id], item)
+		}
+	}
+
+	var content []*yaml.Node
+	for i, item := range fresh.Content {
+		id := identity(item)
+		if candidates := available[id]; id != "" && len(candidates) > 0 {
+			reused := candidates[0]
+			available[id] = candidates[1:]
+			updateNode(reused, item)
+			content = append(content, reused)
+			continue
+		}
+		// Items without identity are matched by position
+		if id == "" && i < len(old.Content) && identity(old.Content[i]) == "" {
+			updateNode(old.Content[i], item)
+			content = append(content, old.Content[i])
+			continue
+		}
+		content = append(content, item)
+	}
+
+	old.Content = content
+}
+
+// replaceNode swaps the node contents while keeping its comments
+func replaceNode(old, fresh *yaml.Node) {
+	head, line, foot := old.HeadComment, old.LineComment, old.FootComment
+	*old = *fresh
+	old.HeadComment, old.LineComment, old.FootComment = head, line, foot
+}
+
+func isEmptyNode(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		return n.Tag == "!!null"
+	}
+	return false
+}
+
+// detectIndent returns the indentation of the first indented line, e.g. 2 for hand-written files
+func detectIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if indent := len(line) - len(trimmed); indent >= 2 && indent <= 8 {
+			return indent
+		}
+		break
+	}
+	return DefaultIndent
+}
+
+// writeFileAtomic writes to a temporary file in the same directory and renames it over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const commentedConfig = `# Categorization rules
+version: 2
+categories:
+  # Groceries and household
+  Food & Drink:
+    subcategory: Groceries
+    keywords:
+      - tesco # All Tesco stores
+      - "spar"
+  Transportation:
+    subcategory: Fuel
+    keywords:
+      - mol
+known_partners:
+  - TESCO
+  - MOL
+`
+
+func TestSaveConfigPreservesComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	if err := os.WriteFile(path, []byte(commentedConfig), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Categories["Food & Drink"].Keywords = append(cfg.Categories["Food & Drink"].Keywords, "lidl")
+	delete(cfg.Categories, "Transportation")
+	cfg.AddKnownPartner("LIDL")
+	if _, err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(data)
+
+	want := `# Categorization rules
+version: 2
+categories:
+  # Groceries and household
+  Food & Drink:
+    subcategory: Groceries
+    keywords:
+      - tesco # All Tesco stores
+      - "spar"
+      - lidl
+known_partners:
+  - TESCO
+  - MOL
+  - LIDL
+`
+	if saved != want {
+		t.Errorf("saved config =\n%s\nwant\n%s", saved, want)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil || string(backup) != commentedConfig {
+		t.Errorf("backup = %q, %v, want the previous version", backup, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("file mode = %v, %v, want 0640 kept", info.Mode().Perm(), err)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*.tmp*")); len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %q", leftovers)
+	}
+}
+
+func TestSaveConfigKeepsPrivateMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	if err := os.WriteFile(path, []byte(commentedConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// An older backup with a wider mode is tightened too
+	if err := os.WriteFile(path+".bak", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.AddKnownPartner("LIDL")
+	if _, err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{path, path + ".bak"} {
+		if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("%s mode = %v, %v, want 0600", filepath.Base(file), info.Mode().Perm(), err)
+		}
+	}
+}
+
+func TestSaveConfigMigratesLikeMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	if err := os.WriteFile(path, []byte(versionOneConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := SaveConfig(path, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 {
+		t.Errorf("steps = %q, want the version 2 migration reported", steps)
+	}
+
+	// Saving an unchanged config writes exactly what migrate-config would
+	migrated, _, _, err := Migrate(path, []byte(versionOneConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(migrated) {
+		t.Errorf("saved config =\n%s\nwant\n%s", data, migrated)
+	}
+
+	// Later saves have nothing left to migrate
+	if steps, err := SaveConfig(path, cfg); err != nil || len(steps) != 0 {
+		t.Errorf("second save = %q, %v, want no steps", steps, err)
+	}
+}
+
+func TestSaveConfigNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	cfg := New()
+	cfg.Categories["Food & Drink"] = &Category{SubCategory: "Groceries", Keywords: []string{"tesco"}}
+	if _, err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\n    Food & Drink:\n        subcategory: Groceries\n") {
+		t.Errorf("new file not indented with DefaultIndent:\n%s", data)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("backup of a new file: %v", err)
+	}
+
+	// Categories with only exact matches don't get an empty keywords list
+	cfg.Categories["Miscellaneous"] = &Category{SubCategory: "Other Income", ExactMatches: []string{"Nagy Anna"}}
+	if _, err := SaveConfig(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "keywords: []") {
+		t.Errorf("saved config contains an empty keywords list:\n%s", data)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Categories["Food & Drink"].Keywords[0] != "tesco" || len(loaded.TypeRules) != len(DefaultTypeRules) {
+		t.Errorf("loaded config = %+v", loaded)
+	}
+}
+
+func TestSaveConfigReplacesBrokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	if err := os.WriteFile(path, []byte("categories: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveConfig(path, New()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err != nil {
+		t.Errorf("LoadConfig after replacing a broken file: %v", err)
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		data string
+		want int
+	}{
+		{"categories:\n  Food:\n", 2},
+		{"# comment\n    # indented comment\nknown_partners:\n    - TESCO\n", 4},
+		{"version: 2\n", DefaultIndent},
+	}
+	for _, tt := range tests {
+		if got := detectIndent([]byte(tt.data)); got != tt.want {
+			t.Errorf("detectIndent(%q) = %d, want %d", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the JSON Schema of a config file, for editors that validate YAML
+//
+//go:embed schema.json
+var Schema []byte
+
+// ValidationError points at the place in a config file that is wrong
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location += fmt.Sprintf(":%d", e.Line)
+		if e.Column > 0 {
+			location += fmt.Sprintf(":%d", e.Column)
+		}
+	}
+	if location == "" {
+		return e.Message
+	}
+	return strings.TrimPrefix(location, ":") + ": " + e.Message
+}
+
+// ValidationErrors lists every problem found in a config file, one per line
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Syntax errors from yaml.v3 look like "yaml: line 3: mapping values are not allowed in this context"
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// decodeStrict parses a single config file, rejecting unknown keys and invalid values
+func decodeStrict(file string, data []byte) (*Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, syntaxError(file, err)
+	}
+
+	var config Config
+	if len(root.Content) == 0 {
+		config.Version = CurrentVersion
+		return &config, nil
+	}
+
+	// Older layouts are upgraded in memory, migrate-config writes the result back
+	if _, _, err := migrateNode(file, root.Content[0]); err != nil {
+		return nil, err
+	}
+
+	v := &validator{file: file}
+	v.config(root.Content[0])
+	if len(v.errors) > 0 {
+		return nil, v.errors
+	}
+
+	if err := root.Decode(&config); err != nil {
+		return nil, &ValidationError{File: file, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	config.ruleless = v.ruleless
+	return &config, nil
+}
+
+func syntaxError(file string, err error) error {
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &ValidationError{File: file, Line: line, Message: m[2]}
+	}
+	return &ValidationError{File: file, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+}
+
+// validator walks the YAML nodes of a config file, so errors carry line and column
+type validator struct {
+	file   string
+	errors ValidationErrors
+
+	// Categories without keywords or exact matches, an error unless an included file adds some
+	ruleless map[string]*ValidationError
+}
+
+func (v *validator) errorf(n *yaml.Node, format string, args ...interface{}) {
+	v.errors = append(v.errors, &ValidationError{
+		File:    v.file,
+		Line:    n.Line,
+		Column:  n.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// fields checks that n is a mapping with only the allowed keys and calls check for each of them
+func (v *validator) fields(n *yaml.Node, what string, allowed []string, check func(key string, value *yaml.Node)) {
+	if n.Kind != yaml.MappingNode {
+		v.errorf(n, "%s must be a mapping", what)
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if !contains(allowed, key.Value) {
+			v.errorf(key, "unknown field %q in %s%s", key.Value, what, suggestField(key.Value, allowed))
+			continue
+		}
+		check(key.Value, value)
+	}
+}
+
+func (v *validator) config(n *yaml.Node) {
+	allowed := []string{"version", "include", "known_partners", "categories", "fuzzy", "merchants", "classifier", "owners", "type_rules"}
+	v.fields(n, "config", allowed, func(key string, value *yaml.Node) {
+		switch key {
+		case "type_rules":
+			v.typeRules(value)
+		case "include", "owners":
+			v.stringList(value, key, false)
+		case "known_partners":
+			v.stringList(value, key, true)
+		case "categories":
+			v.categories(value)
+		case "fuzzy":
+			v.fields(value, "fuzzy", []string{"threshold"}, func(_ string, value *yaml.Node) {
+				v.fraction(value, "fuzzy.threshold")
+			})
+		case "merchants":
+			v.merchants(value)
+		case "classifier":
+			v.fields(value, "classifier", []string{"model", "min_confidence"}, func(key string, value *yaml.Node) {
+				if key == "model" {
+					v.nonEmptyString(value, "classifier.model")
+				} else {
+					v.fraction(value, "classifier.min_confidence")
+				}
+			})
+		}
+	})
+}
+
+func (v *validator) categories(n *yaml.Node) {
+	if isNull(n) {
+		return
+	}
+	if n.Kind != yaml.MappingNode {
+		v.errorf(n, "categories must be a mapping of category names")
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		name := key.Value
+		if strings.TrimSpace(name) == "" {
+			v.errorf(key, "empty category name")
+		}
+		if !hasItems(mappingValue(value, "keywords")) && !hasItems(mappingValue(value, "exact_matches")) {
+			if v.ruleless == nil {
+				v.ruleless = make(map[string]*ValidationError)
+			}
+			v.ruleless[name] = &ValidationError{File: v.file, Line: key.Line, Column: key.Column,
+				Message: fmt.Sprintf("category %q has no keywords or exact_matches, it would never match", name)}
+		}
+		if isNull(value) {
+			continue
+		}
+		what := fmt.Sprintf("category %q", name)
+		v.fields(value, what, []string{"subcategory", "keywords", "exact_matches"}, func(key string, value *yaml.Node) {
+			switch key {
+			case "subcategory":
+				v.nonEmptyString(value, what+" subcategory")
+			case "keywords":
+				v.stringList(value, what+" keywords", true)
+			case "exact_matches":
+				v.stringList(value, what+" exact_matches", true)
+			}
+		})
+	}
+}
+
+func (v *validator) merchants(n *yaml.Node) {
+	if isNull(n) {
+		return
+	}
+	if n.Kind != yaml.SequenceNode {
+		v.errorf(n, "merchants must be a list")
+		return
+	}
+	names := make(map[string]int)
+	for _, item := range n.Content {
+		name := ""
+		v.fields(item, "merchant", []string{"name", "patterns"}, func(key string, value *yaml.Node) {
+			switch key {
+			case "name":
+				if v.nonEmptyString(value, "merchant name") {
+					name = value.Value
+					if line, ok := names[name]; ok {
+						v.errorf(value, "merchant %q is already defined at line %d", name, line)
+					}
+					names[name] = value.Line
+				}
+			case "patterns":
+				if !v.stringList(value, "merchant patterns", true) {
+					return
+				}
+				for _, pattern := range value.Content {
+					if _, err := regexp.Compile(pattern.Value); err != nil {
+						v.errorf(pattern, "invalid pattern %q: %v", pattern.Value, err)
+					}
+				}
+			}
+		})
+		if item.Kind == yaml.MappingNode && name == "" && mappingValue(item, "name") == nil {
+			v.errorf(item, "merchant has no name")
+		}
+	}
+}
+
+func (v *validator) typeRules(n *yaml.Node) {
+	if isNull(n) {
+		return
+	}
+	if n.Kind != yaml.SequenceNode {
+		v.errorf(n, "type_rules must be a list")
+		return
+	}
+	for _, item := range n.Content {
+		v.fields(item, "type rule", []string{"patterns", "category", "subcategory"}, func(key string, value *yaml.Node) {
+			switch key {
+			case "patterns":
+				v.stringList(value, "type rule patterns", true)
+			default:
+				v.nonEmptyString(value, "type rule "+key)
+			}
+		})
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for _, required := range []string{"patterns", "category"} {
+			if mappingValue(item, required) == nil {
+				v.errorf(item, "type rule has no %s", required)
+			}
+		}
+	}
+}
+
+// stringList checks for a list of non-empty strings, optionally without duplicates
+func (v *validator) stringList(n *yaml.Node, what string, unique bool) bool {
+	if isNull(n) {
+		return true
+	}
+	if n.Kind != yaml.SequenceNode {
+		v.errorf(n, "%s must be a list", what)
+		return false
+	}
+	ok := true
+	seen := make(map[string]int)
+	for _, item := range n.Content {
+		if item.Kind != yaml.ScalarNode {
+			v.errorf(item, "%s must only contain strings, one per line", what)
+			ok = false
+			continue
+		}
+		if strings.TrimSpace(item.Value) == "" {
+			v.errorf(item, "empty entry in %s", what)
+			ok = false
+			continue
+		}
+		if line, duplicate := seen[item.Value]; duplicate && unique {
+			v.errorf(item, "duplicate %q in %s, first listed at line %d", item.Value, what, line)
+			continue
+		}
+		seen[item.Value] = item.Line
+	}
+	return ok
+}
+
+func (v *validator) nonEmptyString(n *yaml.Node, what string) bool {
+	if n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+		v.errorf(n, "%s must be a string", what)
+		return false
+	}
+	if strings.TrimSpace(n.Value) == "" {
+		v.errorf(n, "%s must not be empty", what)
+		return false
+	}
+	return true
+}
+
+func (v *validator) fraction(n *yaml.Node, what string) {
+	value, err := strconv.ParseFloat(n.Value, 64)
+	if n.Kind != yaml.ScalarNode || err != nil {
+		v.errorf(n, "%s must be a number", what)
+		return
+	}
+	if value < 0 || value > 1 {
+		v.errorf(n, "%s must be between 0 and 1, got %s", what, n.Value)
+	}
+}
+
+// checkRules reports categories that end up without keywords or exact matches once includes are layered
+// Each one is reported where the file with the highest precedence defines it
+func checkRules(config *Config, path string) error {
+	names := make([]string, 0, len(config.Categories))
+	for name := range config.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errors ValidationErrors
+	for _, name := range names {
+		if category := config.Categories[name]; category != nil && (len(category.Keywords) > 0 || len(category.ExactMatches) > 0) {
+			continue
+		}
+		err := config.ruleless[name]
+		if err == nil {
+			err = &ValidationError{File: path, Message: fmt.Sprintf("category %q has no keywords or exact_matches, it would never match", name)}
+		}
+		errors = append(errors, err)
+	}
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+func hasItems(n *yaml.Node) bool {
+	return n != nil && n.Kind == yaml.SequenceNode && len(n.Content) > 0
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestField proposes the closest allowed key for a typo like "subcatgory"
+func suggestField(key string, allowed []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range allowed {
+		if d := editDistance(strings.ToLower(key), candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func validationMessages(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	return strings.Split(err.Error(), "\n")
+}
+
+func TestDecodeStrict(t *testing.T) {
+	data := `known_partners:
+  - TESCO
+  - TESCO
+  - ""
+categories:
+  Food & Drink:
+    subcatgory: Groceries
+    keywords: tesco
+fuzzy:
+  threshold: 1.5
+merchants:
+  - name: DM
+    patterns:
+      - "^DM ("
+  - patterns:
+      - lidl
+classifier:
+  min_confidence: high
+colors: true
+`
+	_, err := decodeStrict("categories.yaml", []byte(data))
+	want := []string{
+		`categories.yaml:3:5: duplicate "TESCO" in known_partners, first listed at line 2`,
+		`categories.yaml:4:5: empty entry in known_partners`,
+		`categories.yaml:7:5: unknown field "subcatgory" in category "Food & Drink", did you mean "subcategory"?`,
+		`categories.yaml:8:15: category "Food & Drink" keywords must be a list`,
+		`categories.yaml:10:14: fuzzy.threshold must be between 0 and 1, got 1.5`,
+		"categories.yaml:14:9: invalid pattern \"^DM (\": error parsing regexp: missing closing ): `^DM (`",
+		`categories.yaml:15:5: merchant has no name`,
+		`categories.yaml:18:19: classifier.min_confidence must be a number`,
+		`categories.yaml:19:1: unknown field "colors" in config`,
+	}
+	if got := validationMessages(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDecodeStrictSyntaxError(t *testing.T) {
+	_, err := decodeStrict("categories.yaml", []byte("categories:\n  Food: [\n"))
+	verr, ok := err.(*ValidationError)
+	if !ok || verr.File != "categories.yaml" || verr.Line == 0 {
+		t.Errorf("error = %#v, want a ValidationError with a line", err)
+	}
+}
+
+func TestLoadConfigRejectsCategoriesWithoutRules(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"categories.yaml": `categories:
+  Food & Drink:
+    subcategory: Groceries
+    keywords: []
+  Empty:
+  Transportation:
+    subcategory: Fuel
+    keywords:
+      - mol
+`,
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "categories.yaml"))
+	path := filepath.Join(dir, "categories.yaml")
+	want := []string{
+		path + `:5:3: category "Empty" has no keywords or exact_matches, it would never match`,
+		path + `:2:3: category "Food & Drink" has no keywords or exact_matches, it would never match`,
+	}
+	if got := validationMessages(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoadConfigAllowsRulesFromIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		// The main file only overrides the subcategory, the rules come from the fragment
+		"categories.yaml": "include:\n  - shared.yaml\ncategories:\n  Food & Drink:\n    subcategory: Groceries\n",
+		"shared.yaml":     "categories:\n  Food & Drink:\n    subcategory: Food\n    keywords:\n      - tesco\n  Empty:\n    subcategory: Other\n",
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "categories.yaml"))
+	want := []string{filepath.Join(dir, "shared.yaml") + `:6:3: category "Empty" has no keywords or exact_matches, it would never match`}
+	if got := validationMessages(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("errors = %q, want %q", got, want)
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"shared.yaml": "categories:\n  Food & Drink:\n    subcategory: Food\n    keywords:\n      - tesco\n",
+	})
+	cfg, err := LoadConfig(filepath.Join(dir, "categories.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if food := cfg.Categories["Food & Drink"]; food.SubCategory != "Groceries" || len(food.Keywords) != 1 {
+		t.Errorf("Food & Drink = %+v", food)
+	}
+}
+
+func TestSchemaIsValidJSON(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, key := range []string{"version", "include", "known_partners", "categories", "fuzzy", "merchants", "classifier", "owners", "type_rules"} {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("Schema has no property %q", key)
+		}
+	}
+}
+
+func TestEmptyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.yaml")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != CurrentVersion || cfg.Categories == nil {
+		t.Errorf("empty config = %+v", cfg)
+	}
+}
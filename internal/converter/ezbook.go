@@ -2,10 +2,10 @@ package converter
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"math"
-	"strconv"
 	"strings"
 	"time"
 
@@ -29,6 +29,12 @@ type EzBookTransaction struct {
 type Converter struct {
 	categorizer *categorizer.Categorizer
 	accountName string
+
+	// Strict makes Stream stop at the first row that can't be read or converted
+	Strict bool
+
+	// Matched, if set, is called with the rule that categorized each converted transaction
+	Matched func(kh *parser.KHTransaction, match categorizer.Match)
 }
 
 // New creates a new Converter
@@ -42,31 +48,89 @@ func New(cat *categorizer.Categorizer, accountName string) *Converter {
 // Convert transforms K&H transactions to ezBookkeeping format
 func (c *Converter) Convert(khTransactions []*parser.KHTransaction) ([]*EzBookTransaction, []error) {
 	var ezTransactions []*EzBookTransaction
-	var errors []error
+	var convErrors []error
 
 	for _, kh := range khTransactions {
 		ez, err := c.convertSingle(kh)
 		if err != nil {
-			errors = append(errors, fmt.Errorf("transaction %s: %w", kh.TransactionID, err))
+			convErrors = append(convErrors, err)
 			continue
 		}
 		ezTransactions = append(ezTransactions, ez)
 	}
 
-	return ezTransactions, errors
+	return ezTransactions, convErrors
+}
+
+// Source yields K&H transactions one at a time, io.EOF after the last one
+// *parser.KHReader is the usual source
+type Source interface {
+	Next() (*parser.KHTransaction, error)
+}
+
+// StreamResult summarizes a streamed conversion
+type StreamResult struct {
+	Read      int                // Rows read from the source
+	Converted int                // Transactions written
+	Errors    []*parser.RowError // Rows that couldn't be read or converted and were skipped
+}
+
+// Stream parses, categorizes and writes transactions row by row, so memory use doesn't grow with the export
+// Bad rows are collected in the result, or stop the stream in strict mode; read and write errors always stop it
+func (c *Converter) Stream(source Source, writer *CSVWriter) (*StreamResult, error) {
+	result := &StreamResult{}
+
+	for {
+		kh, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+
+		var rowErr *parser.RowError
+		if errors.As(err, &rowErr) {
+			result.Read++
+			if c.Strict {
+				return result, rowErr
+			}
+			result.Errors = append(result.Errors, rowErr)
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Read++
+
+		ez, err := c.convertSingle(kh)
+		if errors.As(err, &rowErr) {
+			if c.Strict {
+				return result, rowErr
+			}
+			result.Errors = append(result.Errors, rowErr)
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if err := writer.Write(ez); err != nil {
+			return result, fmt.Errorf("failed to write transaction %s: %w", kh.TransactionID, err)
+		}
+		result.Converted++
+	}
+
+	return result, writer.Flush()
 }
 
 func (c *Converter) convertSingle(kh *parser.KHTransaction) (*EzBookTransaction, error) {
 	// Parse date
 	date, err := parser.ParseDate(kh.Date)
 	if err != nil {
-		return nil, err
+		return nil, kh.FieldError("Date", err)
 	}
 
 	// Parse amount
-	amount, err := parseAmount(kh.Amount)
+	amount, err := parser.ParseAmount(kh.Amount)
 	if err != nil {
-		return nil, err
+		return nil, kh.FieldError("Amount", err)
 	}
 
 	// Determine transaction type
@@ -77,7 +141,11 @@ func (c *Converter) convertSingle(kh *parser.KHTransaction) (*EzBookTransaction,
 	amount = math.Abs(amount)
 
 	// Categorize
-	category, subCategory := c.categorizer.Categorize(kh.PartnerName, kh.Type)
+	match := c.categorizer.Match(kh.PartnerName, kh.Type, kh.Description)
+	category, subCategory := match.Category, match.SubCategory
+	if c.Matched != nil {
+		c.Matched(kh, match)
+	}
 
 	// If no subcategory was assigned, use default based on transaction type
 	if subCategory == "" {
@@ -89,7 +157,7 @@ func (c *Converter) convertSingle(kh *parser.KHTransaction) (*EzBookTransaction,
 	}
 
 	// Build description
-	description := buildDescription(kh)
+	description := buildDescription(kh, match.Merchant)
 
 	return &EzBookTransaction{
 		Type:        transactionType,
@@ -105,8 +173,29 @@ func (c *Converter) convertSingle(kh *parser.KHTransaction) (*EzBookTransaction,
 
 // WriteCSV writes ezBookkeeping transactions to CSV
 func WriteCSV(writer io.Writer, transactions []*EzBookTransaction) error {
+	csvWriter, err := NewCSVWriter(writer)
+	if err != nil {
+		return err
+	}
+
+	// Write transactions
+	for _, t := range transactions {
+		if err := csvWriter.Write(t); err != nil {
+			return err
+		}
+	}
+
+	return csvWriter.Flush()
+}
+
+// CSVWriter writes ezBookkeeping transactions one at a time
+type CSVWriter struct {
+	csv *csv.Writer
+}
+
+// NewCSVWriter writes the ezBookkeeping header and returns a writer for the transactions
+func NewCSVWriter(writer io.Writer) (*CSVWriter, error) {
 	csvWriter := csv.NewWriter(writer)
-	defer csvWriter.Flush()
 
 	// Write header with ezBookkeeping complete export format
 	// All 14 columns are required for ezBookkeeping Data Export File format
@@ -127,45 +216,106 @@ func WriteCSV(writer io.Writer, transactions []*EzBookTransaction) error {
 		"Description",
 	}
 	if err := csvWriter.Write(header); err != nil {
-		return err
+		return nil, err
 	}
 
-	// Write transactions
-	for _, t := range transactions {
-		record := []string{
-			t.DateTime,
-			"+01:00",        // Timezone (Central European Time - Hungary)
-			t.Type,
-			t.Category,
-			t.SubCategory,
-			t.Account,
-			"HUF",           // Account Currency
-			t.Amount,
-			"",              // Account2 (for transfers)
-			"",              // Account2 Currency
-			"",              // Account2 Amount
-			"",              // Geographic Location
-			t.Tags,
-			t.Description,
+	return &CSVWriter{csv: csvWriter}, nil
+}
+
+// Write writes a single transaction
+func (w *CSVWriter) Write(t *EzBookTransaction) error {
+	record := []string{
+		t.DateTime,
+		"+01:00",        // Timezone (Central European Time - Hungary)
+		t.Type,
+		t.Category,
+		t.SubCategory,
+		t.Account,
+		"HUF",           // Account Currency
+		t.Amount,
+		"",              // Account2 (for transfers)
+		"",              // Account2 Currency
+		"",              // Account2 Amount
+		"",              // Geographic Location
+		t.Tags,
+		t.Description,
+	}
+	return w.csv.Write(record)
+}
+
+// Flush writes any buffered rows and reports write errors
+func (w *CSVWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// ReadCSV reads ezBookkeeping transactions from a CSV export
+// Columns are located by header name, so both our output and ezBookkeeping exports are accepted
+func ReadCSV(reader io.Reader) ([]*EzBookTransaction, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV: %w", err)
+	}
+
+	if len(records) < 1 {
+		return nil, fmt.Errorf("file must contain a header row")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+
+	for _, required := range []string{"Time", "Type", "Category", "Sub Category", "Amount", "Description"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column: %s", required)
 		}
-		if err := csvWriter.Write(record); err != nil {
-			return err
+	}
+
+	field := func(record []string, name string) string {
+		index, ok := columns[name]
+		if !ok || index >= len(record) {
+			return ""
 		}
+		return strings.TrimSpace(record[index])
+	}
+
+	var transactions []*EzBookTransaction
+	for _, record := range records[1:] {
+		transactions = append(transactions, &EzBookTransaction{
+			Type:        field(record, "Type"),
+			Category:    field(record, "Category"),
+			SubCategory: field(record, "Sub Category"),
+			Account:     field(record, "Account"),
+			Amount:      field(record, "Amount"),
+			DateTime:    field(record, "Time"),
+			Description: field(record, "Description"),
+			Tags:        field(record, "Tags"),
+		})
 	}
 
-	return nil
+	return transactions, nil
 }
 
-func parseAmount(amountStr string) (float64, error) {
-	amountStr = strings.ReplaceAll(amountStr, " ", "")
-	amountStr = strings.ReplaceAll(amountStr, ",", ".")
-	
-	amount, err := strconv.ParseFloat(amountStr, 64)
-	if err != nil {
-		return 0, fmt.Errorf("invalid amount: %s", amountStr)
+// ParseDescription splits a description built by buildDescription into its parts
+// The transaction type is the first part wrapped in parentheses, everything before it is the partner
+func ParseDescription(description string) (partnerName, transactionType, note string) {
+	parts := strings.Split(description, " - ")
+
+	for i, part := range parts {
+		if strings.HasPrefix(part, "(") && strings.HasSuffix(part, ")") {
+			partnerName = strings.Join(parts[:i], " - ")
+			transactionType = strings.TrimSuffix(strings.TrimPrefix(part, "("), ")")
+			note = strings.Join(parts[i+1:], " - ")
+			return partnerName, transactionType, note
+		}
 	}
-	
-	return amount, nil
+
+	// No type part, treat the whole description as partner name
+	return description, "", ""
 }
 
 func formatAmount(amount float64) string {
@@ -176,11 +326,12 @@ func formatDateTime(t time.Time) string {
 	return t.Format("2006-01-02 15:04:05")
 }
 
-func buildDescription(kh *parser.KHTransaction) string {
+// buildDescription joins the canonical merchant name, type and note
+func buildDescription(kh *parser.KHTransaction, merchantName string) string {
 	var parts []string
 
-	if kh.PartnerName != "" {
-		parts = append(parts, kh.PartnerName)
+	if merchantName != "" {
+		parts = append(parts, merchantName)
 	}
 
 	if kh.Type != "" {
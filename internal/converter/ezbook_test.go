@@ -0,0 +1,228 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/parser"
+)
+
+const khHeader = "könyvelés dátuma\ttranzakció azonosító\ttípus\tkönyvelési számla\tkönyvelési számla elnevezése\t" +
+	"partner számla\tpartner elnevezése\tösszeg\tösszeg devizaneme\tközlemény\n"
+
+// khExport generates an export of n rows lazily, so reading it never holds the whole file
+type khExport struct {
+	rows    int
+	next    int
+	pending []byte
+	eof     bool // Set once the last row has been handed out
+}
+
+func (e *khExport) Read(p []byte) (int, error) {
+	for len(e.pending) == 0 {
+		switch {
+		case e.next > e.rows:
+			e.eof = true
+			return 0, io.EOF
+		case e.next == 0:
+			e.pending = []byte(khHeader)
+		default:
+			e.pending = fmt.Appendf(nil, "2024.03.%02d\t%d\tKártyás vásárlás\t1040\tFolyószámla\t\tTESCO %d\t-1 200,50\tHUF\t\n",
+				e.next%28+1, e.next, e.next%50)
+		}
+		e.next++
+	}
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+	return n, nil
+}
+
+// watchedWriter records whether the export was still being read when output arrived
+type watchedWriter struct {
+	export        *khExport
+	bytes         int
+	beforeEOF     int
+	rowsReadFirst int // Rows generated when the first output arrived
+}
+
+func (w *watchedWriter) Write(p []byte) (int, error) {
+	if w.bytes == 0 {
+		w.rowsReadFirst = w.export.next
+	}
+	w.bytes += len(p)
+	if !w.export.eof {
+		w.beforeEOF += len(p)
+	}
+	return len(p), nil
+}
+
+func streamConverter() *Converter {
+	cfg := config.New()
+	cfg.Categories["Food & Drink"] = &config.Category{SubCategory: "Groceries", Keywords: []string{"tesco"}}
+	return New(categorizer.New(cfg), "Bank")
+}
+
+func TestStream(t *testing.T) {
+	const rows = 20000
+	export := &khExport{rows: rows}
+	output := &watchedWriter{export: export}
+
+	writer, err := NewCSVWriter(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := streamConverter().Stream(parser.NewKHReader(export, "export.tsv"), writer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Read != rows || result.Converted != rows || len(result.Errors) != 0 {
+		t.Errorf("result = %+v, want %d rows converted", result, rows)
+	}
+	// Rows are written while the export is read, not after it has been read whole
+	if output.beforeEOF == 0 || output.rowsReadFirst > rows/10 {
+		t.Errorf("first output after %d of %d rows, %d of %d bytes before EOF",
+			output.rowsReadFirst, rows, output.beforeEOF, output.bytes)
+	}
+}
+
+func TestStreamOutput(t *testing.T) {
+	export := khHeader +
+		"2024.03.01\t1\tKártyás vásárlás\t1040\tFolyószámla\t\tTESCO ÁRUHÁZ\t-1 200,50\tHUF\t\n" +
+		"2024.03.02\t2\tÁtutalás jóváírás\t1040\tFolyószámla\t1177\tKovács Béla\t15 000\tHUF\tVacsora\n"
+
+	var buf bytes.Buffer
+	writer, err := NewCSVWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := streamConverter().Stream(parser.NewKHReader(strings.NewReader(export), "export.tsv"), writer); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Time,Timezone,Type,Category,Sub Category,Account,Account Currency,Amount,Account2,Account2 Currency,Account2 Amount,Geographic Location,Tags,Description\n" +
+		"2024-03-01 00:00:00,+01:00,Expense,Food & Drink,Groceries,Bank,HUF,1200.50,,,,,,TESCO ÁRUHÁZ - (Kártyás vásárlás)\n" +
+		"2024-03-02 00:00:00,+01:00,Income,Miscellaneous,Other Income,Bank,HUF,15000.00,,,,,,Kovács Béla - (Átutalás jóváírás) - Vacsora\n"
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	transactions, err := ReadCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 || transactions[1].Category != "Miscellaneous" || transactions[1].Amount != "15000.00" {
+		t.Errorf("ReadCSV = %+v", transactions)
+	}
+}
+
+func TestStreamRowErrors(t *testing.T) {
+	export := khHeader +
+		"2024.03.01\t1\tKártyás vásárlás\t1040\tFolyószámla\t\tTESCO\t-1 200\tHUF\t\n" +
+		"2024.13.45\t2\tKártyás vásárlás\t1040\tFolyószámla\t\tSPAR\t-500\tHUF\t\n" +
+		"2024.03.03\t3\tKártyás vásárlás\n" +
+		"2024.03.04\t4\tKártyás vásárlás\t1040\tFolyószámla\t\tALDI\t-800\tHUF\t\n"
+
+	var buf bytes.Buffer
+	writer, err := NewCSVWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := streamConverter().Stream(parser.NewKHReader(strings.NewReader(export), "export.tsv"), writer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Read != 4 || result.Converted != 2 {
+		t.Errorf("result = %+v, want 4 read and 2 converted", result)
+	}
+	var got []string
+	for _, rowErr := range result.Errors {
+		got = append(got, rowErr.Error())
+	}
+	want := []string{
+		"export.tsv:3: column 1 (könyvelés dátuma): invalid date format: 2024.13.45",
+		"export.tsv:4: expected at least 9 columns, got 3",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Strict mode stops at the first bad row
+	conv := streamConverter()
+	conv.Strict = true
+	writer, _ = NewCSVWriter(io.Discard)
+	result, err = conv.Stream(parser.NewKHReader(strings.NewReader(export), "export.tsv"), writer)
+	var rowErr *parser.RowError
+	if !errors.As(err, &rowErr) || rowErr.Line != 3 {
+		t.Errorf("strict Stream error = %v, want the row error of line 3", err)
+	}
+	if result.Read != 2 || result.Converted != 1 {
+		t.Errorf("strict result = %+v, want 2 read and 1 converted", result)
+	}
+}
+
+func TestStreamMatched(t *testing.T) {
+	export := khHeader +
+		"2024.03.01\t1\tKártyás vásárlás\t1040\tFolyószámla\t\tTESCO\t-1 200\tHUF\t\n" +
+		"2024.13.45\t2\tKártyás vásárlás\t1040\tFolyószámla\t\tSPAR\t-500\tHUF\t\n" +
+		"2024.03.04\t4\tKártyás vásárlás\t1040\tFolyószámla\t\tALDI\t-800\tHUF\t\n"
+
+	writer, err := NewCSVWriter(io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conv := streamConverter()
+	var matched []string
+	conv.Matched = func(kh *parser.KHTransaction, match categorizer.Match) {
+		matched = append(matched, kh.PartnerName+":"+match.Tier)
+	}
+	if _, err := conv.Stream(parser.NewKHReader(strings.NewReader(export), "export.tsv"), writer); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once per converted row, the rejected SPAR row is never categorized
+	want := []string{"TESCO:keyword", "ALDI:default"}
+	if strings.Join(matched, ",") != strings.Join(want, ",") {
+		t.Errorf("Matched called with %v, want %v", matched, want)
+	}
+}
+
+func TestParseDescription(t *testing.T) {
+	tests := []struct {
+		description, partner, transactionType, note string
+	}{
+		{"TESCO - (Kártyás vásárlás) - heti bevásárlás", "TESCO", "Kártyás vásárlás", "heti bevásárlás"},
+		{"A - B KFT - (Átutalás)", "A - B KFT", "Átutalás", ""},
+		{"TESCO", "TESCO", "", ""},
+	}
+	for _, tt := range tests {
+		partner, transactionType, note := ParseDescription(tt.description)
+		if partner != tt.partner || transactionType != tt.transactionType || note != tt.note {
+			t.Errorf("ParseDescription(%q) = %q, %q, %q", tt.description, partner, transactionType, note)
+		}
+	}
+}
+
+func BenchmarkStream(b *testing.B) {
+	for _, rows := range []int{1000, 100000} {
+		b.Run(fmt.Sprint(rows), func(b *testing.B) {
+			converter := streamConverter()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				writer, err := NewCSVWriter(io.Discard)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := converter.Stream(parser.NewKHReader(&khExport{rows: rows}, "export.tsv"), writer); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package learner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/converter"
+)
+
+// Correction is a transaction whose category was changed in ezBookkeeping
+type Correction struct {
+	PartnerName     string
+	DateTime        string
+	Amount          string
+	FromCategory    string
+	FromSubCategory string
+	ToCategory      string
+	ToSubCategory   string
+}
+
+// Proposal is a new exact match rule learned from corrections
+type Proposal struct {
+	PartnerName string
+	Category    string
+	SubCategory string
+	Corrections int
+	NewCategory bool     // Category does not exist in the config yet
+	RemoveFrom  []string // Categories that currently list the partner as exact match
+}
+
+// FindCorrections joins exported transactions back to original conversions
+// Rows are matched by time, amount and description; returns the number of original rows not found in the export
+func FindCorrections(original, exported []*converter.EzBookTransaction) ([]Correction, int) {
+	// Several transactions can share a key, so keep a queue per key
+	index := make(map[string][]*converter.EzBookTransaction)
+	for _, t := range exported {
+		key := joinKey(t)
+		index[key] = append(index[key], t)
+	}
+
+	var corrections []Correction
+	unmatched := 0
+
+	for _, orig := range original {
+		key := joinKey(orig)
+		candidates := index[key]
+		if len(candidates) == 0 {
+			unmatched++
+			continue
+		}
+		exp := candidates[0]
+		index[key] = candidates[1:]
+
+		if exp.Category == orig.Category && exp.SubCategory == orig.SubCategory {
+			continue
+		}
+
+		partnerName, _, _ := converter.ParseDescription(orig.Description)
+		corrections = append(corrections, Correction{
+			PartnerName:     partnerName,
+			DateTime:        orig.DateTime,
+			Amount:          orig.Amount,
+			FromCategory:    orig.Category,
+			FromSubCategory: orig.SubCategory,
+			ToCategory:      exp.Category,
+			ToSubCategory:   exp.SubCategory,
+		})
+	}
+
+	return corrections, unmatched
+}
+
+// Propose turns corrections into exact match rules for the config
+// Partners corrected to different categories, or to a subcategory the config cannot express, are skipped with a reason
+func Propose(cfg *config.Config, corrections []Correction) ([]Proposal, []string) {
+	byPartner := make(map[string][]Correction)
+	var partners []string
+	for _, c := range corrections {
+		if strings.TrimSpace(c.PartnerName) == "" {
+			continue
+		}
+		if _, ok := byPartner[c.PartnerName]; !ok {
+			partners = append(partners, c.PartnerName)
+		}
+		byPartner[c.PartnerName] = append(byPartner[c.PartnerName], c)
+	}
+	sort.Strings(partners)
+
+	var proposals []Proposal
+	var skipped []string
+
+	for _, partner := range partners {
+		group := byPartner[partner]
+		target := group[0]
+
+		conflict := false
+		for _, c := range group[1:] {
+			if c.ToCategory != target.ToCategory || c.ToSubCategory != target.ToSubCategory {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			skipped = append(skipped, fmt.Sprintf("%q: corrected to different categories, add a rule manually", partner))
+			continue
+		}
+
+		proposal := Proposal{
+			PartnerName: partner,
+			Category:    target.ToCategory,
+			SubCategory: target.ToSubCategory,
+			Corrections: len(group),
+		}
+
+		category, ok := cfg.Categories[target.ToCategory]
+		if !ok {
+			proposal.NewCategory = true
+		} else if category.SubCategory != target.ToSubCategory {
+			skipped = append(skipped, fmt.Sprintf("%q: %s uses subcategory %q in the config, not %q",
+				partner, target.ToCategory, category.SubCategory, target.ToSubCategory))
+			continue
+		} else if containsString(category.ExactMatches, partner) {
+			continue // Rule already present, the config was fixed since the conversion
+		}
+
+		for name, category := range cfg.Categories {
+			if name != target.ToCategory && containsString(category.ExactMatches, partner) {
+				proposal.RemoveFrom = append(proposal.RemoveFrom, name)
+			}
+		}
+		sort.Strings(proposal.RemoveFrom)
+
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, skipped
+}
+
+// Apply writes the proposed exact matches into the config
+// A category left without keywords or exact matches is removed, since the config would no longer load
+func Apply(cfg *config.Config, proposals []Proposal) {
+	for _, p := range proposals {
+		for _, name := range p.RemoveFrom {
+			category := cfg.Categories[name]
+			category.ExactMatches = removeString(category.ExactMatches, p.PartnerName)
+			if len(category.Keywords) == 0 && len(category.ExactMatches) == 0 {
+				delete(cfg.Categories, name)
+			}
+		}
+
+		category, ok := cfg.Categories[p.Category]
+		if !ok {
+			category = &config.Category{SubCategory: p.SubCategory}
+			cfg.Categories[p.Category] = category
+		}
+		if !containsString(category.ExactMatches, p.PartnerName) {
+			category.ExactMatches = append(category.ExactMatches, p.PartnerName)
+		}
+
+		cfg.AddKnownPartner(p.PartnerName)
+	}
+}
+
+// joinKey identifies a transaction by time, amount and description
+// Amounts are normalized since ezBookkeeping may export them with different precision
+func joinKey(t *converter.EzBookTransaction) string {
+	amount := t.Amount
+	if value, err := strconv.ParseFloat(strings.ReplaceAll(amount, ",", ""), 64); err == nil {
+		if value < 0 {
+			value = -value
+		}
+		amount = strconv.FormatFloat(value, 'f', 2, 64)
+	}
+	return t.DateTime + "\x00" + amount + "\x00" + t.Description
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, value string) []string {
+	var result []string
+	for _, item := range list {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package learner
+
+import (
+	"reflect"
+	"testing"
+
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/converter"
+)
+
+func transaction(dateTime, amount, partner, category, subCategory string) *converter.EzBookTransaction {
+	return &converter.EzBookTransaction{
+		DateTime:    dateTime,
+		Amount:      amount,
+		Description: partner + " - (Kártyás vásárlás)",
+		Category:    category,
+		SubCategory: subCategory,
+	}
+}
+
+func TestFindCorrections(t *testing.T) {
+	original := []*converter.EzBookTransaction{
+		transaction("2024-03-01 10:00:00", "-1200.00", "TESCO", "Miscellaneous", "Other Expense"),
+		transaction("2024-03-01 10:00:00", "-1200.00", "TESCO", "Miscellaneous", "Other Expense"),
+		transaction("2024-03-02 11:00:00", "-5000.00", "MOL", "Transportation", "Fuel"),
+		transaction("2024-03-03 12:00:00", "-300.00", "BKV", "Transportation", "Public Transit"),
+	}
+	exported := []*converter.EzBookTransaction{
+		transaction("2024-03-01 10:00:00", "1200", "TESCO", "Food & Drink", "Groceries"),
+		transaction("2024-03-01 10:00:00", "1,200.0", "TESCO", "Miscellaneous", "Other Expense"),
+		transaction("2024-03-02 11:00:00", "5000", "MOL", "Transportation", "Fuel"),
+	}
+
+	corrections, unmatched := FindCorrections(original, exported)
+	if unmatched != 1 {
+		t.Errorf("unmatched = %d, want 1", unmatched)
+	}
+	if len(corrections) != 1 {
+		t.Fatalf("corrections = %+v, want one", corrections)
+	}
+	if c := corrections[0]; c.PartnerName != "TESCO" || c.ToCategory != "Food & Drink" || c.FromCategory != "Miscellaneous" {
+		t.Errorf("correction = %+v, want TESCO from Miscellaneous to Food & Drink", c)
+	}
+}
+
+func TestProposeAndApply(t *testing.T) {
+	cfg := config.New()
+	cfg.Categories["Food & Drink"] = &config.Category{SubCategory: "Groceries", Keywords: []string{"spar"}}
+	cfg.Categories["Miscellaneous"] = &config.Category{SubCategory: "Other Expense", ExactMatches: []string{"TESCO"}}
+	cfg.Categories["Transportation"] = &config.Category{SubCategory: "Fuel", Keywords: []string{"omv"}}
+
+	corrections := []Correction{
+		{PartnerName: "TESCO", ToCategory: "Food & Drink", ToSubCategory: "Groceries"},
+		{PartnerName: "TESCO", ToCategory: "Food & Drink", ToSubCategory: "Groceries"},
+		{PartnerName: "ALDI", ToCategory: "Food & Drink", ToSubCategory: "Groceries"},
+		{PartnerName: "ALDI", ToCategory: "Clothing & Appearance", ToSubCategory: "Clothing"},
+		{PartnerName: "MOL", ToCategory: "Transportation", ToSubCategory: "Parking"},
+		{PartnerName: "Netflix", ToCategory: "Entertainment", ToSubCategory: "Streaming"},
+		{PartnerName: " ", ToCategory: "Entertainment"},
+	}
+
+	proposals, skipped := Propose(cfg, corrections)
+	want := []Proposal{
+		{PartnerName: "Netflix", Category: "Entertainment", SubCategory: "Streaming", Corrections: 1, NewCategory: true},
+		{PartnerName: "TESCO", Category: "Food & Drink", SubCategory: "Groceries", Corrections: 2, RemoveFrom: []string{"Miscellaneous"}},
+	}
+	if !reflect.DeepEqual(proposals, want) {
+		t.Errorf("proposals = %+v, want %+v", proposals, want)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("skipped = %q, want ALDI and MOL", skipped)
+	}
+
+	Apply(cfg, proposals)
+	if got := cfg.Categories["Food & Drink"].ExactMatches; !reflect.DeepEqual(got, []string{"TESCO"}) {
+		t.Errorf("Food & Drink exact matches = %q", got)
+	}
+	// TESCO was the only rule of Miscellaneous, so the category goes with it
+	if category, ok := cfg.Categories["Miscellaneous"]; ok {
+		t.Errorf("Miscellaneous = %+v, want it removed", category)
+	}
+	if category := cfg.Categories["Entertainment"]; category == nil || category.SubCategory != "Streaming" {
+		t.Errorf("Entertainment = %+v, want a new category with subcategory Streaming", category)
+	}
+	if !cfg.IsKnownPartner("Netflix") || !cfg.IsKnownPartner("TESCO") {
+		t.Errorf("known partners = %q, want Netflix and TESCO", cfg.KnownPartners)
+	}
+
+	// Corrections already in the config are not proposed again
+	if proposals, _ := Propose(cfg, corrections[:2]); len(proposals) != 0 {
+		t.Errorf("proposals after Apply = %+v, want none", proposals)
+	}
+}
@@ -0,0 +1,208 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/parser"
+)
+
+// Issue is a problem found in the config
+type Issue struct {
+	Severity string // "error", "warning"
+	Category string // Category the issue belongs to, empty for config-wide issues
+	Message  string
+}
+
+func (i Issue) String() string {
+	if i.Category == "" {
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Category, i.Message)
+}
+
+// Lint checks the config for conflicting, redundant and unknown rules
+func Lint(cfg *config.Config) []Issue {
+	var issues []Issue
+	names := sortedCategories(cfg)
+
+	issues = append(issues, lintCategories(cfg, names)...)
+	issues = append(issues, lintKeywords(cfg, names)...)
+	issues = append(issues, lintExactMatches(cfg, names)...)
+
+	return issues
+}
+
+// LintUsage reports keywords and exact matches that match none of the transactions
+func LintUsage(cfg *config.Config, cat *categorizer.Categorizer, transactions []*parser.KHTransaction) []Issue {
+	used := make(map[string]bool)
+	for _, t := range transactions {
+		for _, m := range cat.Explain(t.PartnerName, t.Type, t.Description, true) {
+			used[ruleKey(m.Tier, m.Category, m.Rule)] = true
+		}
+	}
+
+	var issues []Issue
+	for _, name := range sortedCategories(cfg) {
+		category := cfg.Categories[name]
+		if category == nil {
+			continue // Reported by Lint
+		}
+		for _, keyword := range category.Keywords {
+			if !used[ruleKey("keyword", name, keyword)] {
+				issues = append(issues, Issue{"warning", name, fmt.Sprintf("keyword %q never matched any transaction", keyword)})
+			}
+		}
+		for _, exactMatch := range category.ExactMatches {
+			if !used[ruleKey("exact", name, exactMatch)] {
+				issues = append(issues, Issue{"warning", name, fmt.Sprintf("exact match %q never matched any transaction", exactMatch)})
+			}
+		}
+	}
+
+	return issues
+}
+
+func lintCategories(cfg *config.Config, names []string) []Issue {
+	known := make(map[string]bool)
+	for _, list := range [][]string{config.DefaultExpenseCategories, config.DefaultIncomeCategories, config.DefaultTransferCategories} {
+		for _, name := range list {
+			known[name] = true
+		}
+	}
+
+	var issues []Issue
+	for _, name := range names {
+		category := cfg.Categories[name]
+		if category == nil {
+			issues = append(issues, Issue{"error", name, "category has no rules"})
+			continue
+		}
+		if strings.TrimSpace(category.SubCategory) == "" {
+			issues = append(issues, Issue{"warning", name, "empty subcategory, transactions fall back to Other Expense/Other Income"})
+		}
+		if len(category.Keywords) == 0 && len(category.ExactMatches) == 0 {
+			issues = append(issues, Issue{"warning", name, "no keywords or exact matches, category never matches"})
+		}
+		if !known[name] {
+			issues = append(issues, Issue{"warning", name, "not an ezBookkeeping default category, create it in ezBookkeeping before importing"})
+		}
+	}
+	return issues
+}
+
+func lintKeywords(cfg *config.Config, names []string) []Issue {
+	var issues []Issue
+
+	// Keyword (lowercase) → categories listing it
+	owners := make(map[string][]string)
+	for _, name := range names {
+		category := cfg.Categories[name]
+		if category == nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, keyword := range category.Keywords {
+			lower := strings.ToLower(strings.TrimSpace(keyword))
+			if lower == "" {
+				issues = append(issues, Issue{"error", name, "empty keyword matches every partner"})
+				continue
+			}
+			if seen[lower] {
+				issues = append(issues, Issue{"warning", name, fmt.Sprintf("duplicate keyword %q", keyword)})
+				continue
+			}
+			seen[lower] = true
+			owners[lower] = append(owners[lower], name)
+		}
+	}
+
+	keywords := make([]string, 0, len(owners))
+	for keyword := range owners {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	for _, keyword := range keywords {
+		if len(owners[keyword]) > 1 {
+			issues = append(issues, Issue{"error", "", fmt.Sprintf("keyword %q is listed in multiple categories: %s",
+				keyword, strings.Join(owners[keyword], ", "))})
+		}
+	}
+
+	// A keyword contained in another category's keyword matches the same partners
+	for _, short := range keywords {
+		for _, long := range keywords {
+			if short == long || !strings.Contains(long, short) {
+				continue
+			}
+			for _, shortOwner := range owners[short] {
+				for _, longOwner := range owners[long] {
+					if shortOwner == longOwner {
+						continue
+					}
+					issues = append(issues, Issue{"warning", shortOwner, fmt.Sprintf("keyword %q is a substring of %q in %s, partners containing %q match both",
+						short, long, longOwner, long)})
+				}
+			}
+		}
+	}
+
+	return issues
+}
+
+func lintExactMatches(cfg *config.Config, names []string) []Issue {
+	var issues []Issue
+
+	owners := make(map[string][]string)
+	for _, name := range names {
+		category := cfg.Categories[name]
+		if category == nil {
+			continue
+		}
+		for _, exactMatch := range category.ExactMatches {
+			owners[exactMatch] = append(owners[exactMatch], name)
+
+			// Exact matches win over keywords, so only a keyword of the same category makes the rule redundant
+			lower := strings.ToLower(exactMatch)
+			for _, keyword := range category.Keywords {
+				keywordLower := strings.ToLower(strings.TrimSpace(keyword))
+				if keywordLower != "" && strings.Contains(lower, keywordLower) {
+					issues = append(issues, Issue{"warning", name, fmt.Sprintf("exact match %q is shadowed by keyword %q", exactMatch, keyword)})
+					break
+				}
+			}
+		}
+	}
+
+	exactMatches := make([]string, 0, len(owners))
+	for exactMatch := range owners {
+		exactMatches = append(exactMatches, exactMatch)
+	}
+	sort.Strings(exactMatches)
+
+	for _, exactMatch := range exactMatches {
+		if len(owners[exactMatch]) > 1 {
+			issues = append(issues, Issue{"error", "", fmt.Sprintf("exact match %q is listed in multiple categories: %s",
+				exactMatch, strings.Join(owners[exactMatch], ", "))})
+		}
+	}
+
+	return issues
+}
+
+func sortedCategories(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Categories))
+	for name := range cfg.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func ruleKey(tier, category, rule string) string {
+	return tier + "\x00" + category + "\x00" + rule
+}
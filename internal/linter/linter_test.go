@@ -0,0 +1,63 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+
+	"ezbook-convert/internal/categorizer"
+	"ezbook-convert/internal/config"
+	"ezbook-convert/internal/parser"
+)
+
+func lintConfig() *config.Config {
+	cfg := config.New()
+	cfg.Categories["Food & Drink"] = &config.Category{
+		SubCategory:  "Groceries",
+		Keywords:     []string{"tesco", "tesco", "spar"},
+		ExactMatches: []string{"TESCO ÁRUHÁZ", "LIDL"},
+	}
+	cfg.Categories["Clothing & Appearance"] = &config.Category{
+		SubCategory: "Clothing",
+		Keywords:    []string{"spar", "h&m"},
+	}
+	cfg.Categories["Empty"] = nil
+	return cfg
+}
+
+func issueStrings(issues []Issue) []string {
+	var lines []string
+	for _, issue := range issues {
+		lines = append(lines, issue.String())
+	}
+	return lines
+}
+
+func TestLint(t *testing.T) {
+	got := issueStrings(Lint(lintConfig()))
+	want := []string{
+		"error: Empty: category has no rules",
+		"warning: Food & Drink: duplicate keyword \"tesco\"",
+		"error: keyword \"spar\" is listed in multiple categories: Clothing & Appearance, Food & Drink",
+		"warning: Food & Drink: exact match \"TESCO ÁRUHÁZ\" is shadowed by keyword \"tesco\"",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLintUsage(t *testing.T) {
+	cfg := lintConfig()
+	transactions := []*parser.KHTransaction{
+		{PartnerName: "TESCO ÁRUHÁZ", Type: "Kártyás vásárlás"},
+		{PartnerName: "SPAR MARKET", Type: "Kártyás vásárlás"},
+	}
+
+	got := issueStrings(LintUsage(cfg, categorizer.New(cfg), transactions))
+	want := []string{
+		"warning: Clothing & Appearance: keyword \"h&m\" never matched any transaction",
+		"warning: Food & Drink: exact match \"LIDL\" never matched any transaction",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LintUsage =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout allows slow local models to answer a long prompt
+const DefaultTimeout = 10 * time.Minute
+
+// DefaultTemperature keeps answers close to deterministic, the reply has to be a valid config
+const DefaultTemperature = 0.2
+
+// Client talks to an OpenAI-compatible chat completions API (Ollama, llama.cpp server, vLLM, ...)
+type Client struct {
+	Endpoint    string       // Base URL including the version, e.g. http://localhost:11434/v1
+	Model       string       // Model name as known by the server
+	APIKey      string       // Optional bearer token
+	Temperature float64      // Sampling temperature, always sent so the server default doesn't apply
+	HTTPClient  *http.Client // Replaceable, e.g. to point at a fake server
+}
+
+// NewClient creates a client with the default timeout and temperature
+func NewClient(endpoint, model string) *Client {
+	return &Client{
+		Endpoint:    strings.TrimRight(endpoint, "/"),
+		Model:       model,
+		Temperature: DefaultTemperature,
+		HTTPClient:  &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	Stream      bool      `json:"stream"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Complete sends a single user message and returns the assistant's reply
+func (c *Client) Complete(ctx context.Context, prompt string) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:       c.Model,
+		Messages:    []message{{Role: "user", Content: prompt}},
+		Temperature: c.Temperature,
+		Stream:      false,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach LLM server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read LLM response: %w", err)
+	}
+
+	var parsed chatResponse
+	jsonErr := json.Unmarshal(data, &parsed)
+
+	if resp.StatusCode != http.StatusOK {
+		if jsonErr == nil && parsed.Error != nil && parsed.Error.Message != "" {
+			return "", fmt.Errorf("LLM server returned %s: %s", resp.Status, parsed.Error.Message)
+		}
+		return "", fmt.Errorf("LLM server returned %s: %s", resp.Status, truncate(string(data), 200))
+	}
+	if jsonErr != nil {
+		return "", fmt.Errorf("invalid LLM response: %w", jsonErr)
+	}
+	if len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("LLM response contains no choices")
+	}
+
+	return parsed.Choices[0].Message.Content, nil
+}
+
+func truncate(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit] + "..."
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	var request chatRequest
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "known_partners: []"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/v1/", "qwen2.5")
+	client.APIKey = "secret"
+
+	answer, err := client.Complete(context.Background(), "Categorize these merchants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "known_partners: []" {
+		t.Errorf("answer = %q", answer)
+	}
+	if path != "/v1/chat/completions" || auth != "Bearer secret" {
+		t.Errorf("request to %s with Authorization %q", path, auth)
+	}
+	if request.Model != "qwen2.5" || request.Stream || request.Temperature != DefaultTemperature ||
+		len(request.Messages) != 1 || request.Messages[0].Role != "user" || request.Messages[0].Content != "Categorize these merchants" {
+		t.Errorf("request = %+v", request)
+	}
+}
+
+func TestCompleteSendsZeroTemperature(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "model")
+	client.Temperature = 0
+	if _, err := client.Complete(context.Background(), "prompt"); err != nil {
+		t.Fatal(err)
+	}
+	if temperature, ok := body["temperature"]; !ok || temperature != 0.0 {
+		t.Errorf("temperature = %v (sent: %v), want 0", temperature, ok)
+	}
+}
+
+func TestCompleteErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"error message", http.StatusNotFound, `{"error": {"message": "model \"qwen\" not found"}}`, `404 Not Found: model "qwen" not found`},
+		{"plain error body", http.StatusBadGateway, "upstream unavailable\n", "502 Bad Gateway: upstream unavailable"},
+		{"long error body", http.StatusInternalServerError, strings.Repeat("x", 300), strings.Repeat("x", 200) + "..."},
+		{"empty choices", http.StatusOK, `{"choices": []}`, "LLM response contains no choices"},
+		{"invalid JSON", http.StatusOK, `not json`, "invalid LLM response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewClient(server.URL, "model").Complete(context.Background(), "prompt")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	if _, err := NewClient(url, "model").Complete(context.Background(), "prompt"); err == nil || !strings.Contains(err.Error(), "failed to reach LLM server") {
+		t.Errorf("error = %v, want failed to reach LLM server", err)
+	}
+}
@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"ezbook-convert/internal/textnorm"
+)
+
+// khField is a KHTransaction field with the header names K&H uses for it
+// Aliases are compared accent- and case-insensitively, so "Összeg" matches "osszeg"
+type khField struct {
+	name     string
+	required bool
+	aliases  []string
+}
+
+var khFields = []khField{
+	{"Date", true, []string{"könyvelés dátuma", "dátum", "booking date", "posting date", "date"}},
+	{"TransactionID", true, []string{"tranzakció azonosító", "azonosító", "transaction id", "transaction identifier", "id"}},
+	{"Type", true, []string{"típus", "tranzakció típusa", "type", "transaction type"}},
+	{"AccountNumber", false, []string{"könyvelési számla", "számlaszám", "booking account", "account number", "account"}},
+	{"AccountName", false, []string{"könyvelési számla elnevezése", "számla elnevezése", "booking account name", "account name"}},
+	{"PartnerAccount", false, []string{"partner számla", "partner számlaszám", "partner account", "partner account number"}},
+	{"PartnerName", true, []string{"partner elnevezése", "partner neve", "partner name", "partner"}},
+	{"Amount", true, []string{"összeg", "amount"}},
+	{"Currency", true, []string{"összeg devizaneme", "devizanem", "pénznem", "currency", "amount currency"}},
+	{"Description", false, []string{"közlemény", "megjegyzés", "description", "narrative", "comment"}},
+	{"ValueDate", false, []string{"értéknap", "value date"}},
+	{"TransactionDate", false, []string{"tranzakció dátuma", "tranzakció időpontja", "transaction date"}},
+	{"OriginalAmount", false, []string{"eredeti összeg", "original amount"}},
+	{"OriginalCurrency", false, []string{"eredeti összeg devizaneme", "eredeti devizanem", "original currency", "original amount currency"}},
+	{"ExchangeRate", false, []string{"árfolyam", "átváltási árfolyam", "exchange rate"}},
+	{"Balance", false, []string{"egyenleg", "könyvelt egyenleg", "balance"}},
+	{"CardNumber", false, []string{"kártyaszám", "kártya száma", "card number"}},
+	{"Location", false, []string{"helyszín", "tranzakció helye", "location", "place"}},
+	{"PartnerBank", false, []string{"partner bankja", "partner bank"}},
+	{"Reference", false, []string{"referencia", "hivatkozás", "reference"}},
+	{"Category", false, []string{"kategória", "category"}},
+}
+
+// khLayout maps the columns of an export to KHTransaction fields by header name
+type khLayout struct {
+	header  []string
+	columns map[string]int // Field name → column index
+	extra   []int          // Columns not mapped to a field
+	width   int            // Rows need at least this many fields to contain every required column
+}
+
+// newKHLayout locates the fields in the header row
+// Returns an error naming every required column that is missing
+func newKHLayout(header []string) (*khLayout, error) {
+	layout := &khLayout{header: header, columns: make(map[string]int)}
+
+	byName := make(map[string]string)
+	for _, field := range khFields {
+		for _, alias := range field.aliases {
+			byName[normalizeHeader(alias)] = field.name
+		}
+	}
+
+	for i, name := range header {
+		field, ok := byName[normalizeHeader(name)]
+		if _, taken := layout.columns[field]; !ok || taken {
+			layout.extra = append(layout.extra, i)
+			continue
+		}
+		layout.columns[field] = i
+	}
+
+	var missing []string
+	for _, field := range khFields {
+		index, ok := layout.columns[field.name]
+		if !ok && field.required {
+			missing = append(missing, fmt.Sprintf("%q", field.aliases[0]))
+		}
+		if ok && field.required && index+1 > layout.width {
+			layout.width = index + 1
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required column(s) %s in header: %s",
+			strings.Join(missing, ", "), strings.Join(header, ", "))
+	}
+
+	return layout, nil
+}
+
+// field returns the trimmed value of a field, "" if the column is absent or the row is short
+func (l *khLayout) field(record []string, name string) string {
+	index, ok := l.columns[name]
+	if !ok {
+		return ""
+	}
+	return getField(record, index)
+}
+
+// extraFields returns the unmapped columns of a row keyed by their header name
+func (l *khLayout) extraFields(record []string) map[string]string {
+	if len(l.extra) == 0 {
+		return nil
+	}
+	extra := make(map[string]string, len(l.extra))
+	for _, index := range l.extra {
+		name := strings.TrimSpace(l.header[index])
+		if name == "" {
+			name = fmt.Sprintf("column %d", index+1)
+		}
+		extra[name] = getField(record, index)
+	}
+	return extra
+}
+
+// normalizeHeader folds accents, case, punctuation and a byte order mark, so header names compare loosely
+func normalizeHeader(name string) string {
+	return strings.Join(textnorm.Tokens(name), " ")
+}
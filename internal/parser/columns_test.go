@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKHFieldAliases(t *testing.T) {
+	fields := make(map[string]string)
+	for _, field := range khFields {
+		for _, alias := range field.aliases {
+			name := normalizeHeader(alias)
+			if other, ok := fields[name]; ok {
+				t.Errorf("alias %q of %s is also an alias of %s", alias, field.name, other)
+			}
+			fields[name] = field.name
+		}
+	}
+
+	// Every field is read into the KHTransaction field of the same name
+	transaction := reflect.TypeOf(KHTransaction{})
+	for _, field := range khFields {
+		if _, ok := transaction.FieldByName(field.name); !ok {
+			t.Errorf("KHTransaction has no field %s", field.name)
+		}
+	}
+}
+
+func TestNewKHLayout(t *testing.T) {
+	// English names, reordered, with a byte order mark and different case and accents
+	header := []string{"\ufeffAmount", "Partner Name", "Transaction ID", "Booking date", "Type", "Currency", "Ertekm", "Osszeg"}
+	layout, err := newKHLayout(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"Amount": 0, "PartnerName": 1, "TransactionID": 2, "Date": 3, "Type": 4, "Currency": 5}
+	if !reflect.DeepEqual(layout.columns, want) {
+		t.Errorf("columns = %v, want %v", layout.columns, want)
+	}
+	// The unknown column and the second amount column are extras
+	if !reflect.DeepEqual(layout.extra, []int{6, 7}) {
+		t.Errorf("extra = %v, want [6 7]", layout.extra)
+	}
+	if layout.width != 6 {
+		t.Errorf("width = %d, want 6", layout.width)
+	}
+
+	_, err = newKHLayout([]string{"Könyvelés dátuma", "Típus", "Partner elnevezése"})
+	want2 := `missing required column(s) "tranzakció azonosító", "összeg", "összeg devizaneme" in header: Könyvelés dátuma, Típus, Partner elnevezése`
+	if err == nil || err.Error() != want2 {
+		t.Errorf("error = %v, want %q", err, want2)
+	}
+}
+
+func TestKHReaderNamedFields(t *testing.T) {
+	header := []string{
+		"könyvelés dátuma", "értéknap", "tranzakció azonosító", "típus", "könyvelési számla", "könyvelési számla elnevezése",
+		"partner számla", "partner elnevezése", "partner bankja", "összeg", "összeg devizaneme", "eredeti összeg",
+		"eredeti összeg devizaneme", "árfolyam", "egyenleg", "közlemény", "kártyaszám", "helyszín", "tranzakció dátuma",
+		"referencia", "kategória", "új oszlop",
+	}
+	row := []string{
+		"2024.03.01", "2024.03.02", "42", "Vásárlás külföldi kereskedőnél", "1040", "Folyószámla",
+		"", "AMAZON EU", "", "-12 345", "HUF", "-30,50",
+		"EUR", "404,75", "1 000 000", "", "1234******5678", "Luxembourg", "2024.02.28",
+		"REF-1", "Vásárlás", "valami",
+	}
+	export := strings.Join(header, "\t") + "\n" + strings.Join(row, "\t") + "\n"
+
+	transactions, rowErrors, err := ParseKHExport(strings.NewReader(export), "export.tsv")
+	if err != nil || len(rowErrors) != 0 || len(transactions) != 1 {
+		t.Fatalf("ParseKHExport = %v, %v, %v", transactions, rowErrors, err)
+	}
+
+	got := *transactions[0]
+	got.Record, got.layout = nil, nil
+	want := KHTransaction{
+		Date:             "2024.03.01",
+		TransactionID:    "42",
+		Type:             "Vásárlás külföldi kereskedőnél",
+		AccountNumber:    "1040",
+		AccountName:      "Folyószámla",
+		PartnerName:      "AMAZON EU",
+		Amount:           "-12 345",
+		Currency:         "HUF",
+		ValueDate:        "2024.03.02",
+		TransactionDate:  "2024.02.28",
+		OriginalAmount:   "-30,50",
+		OriginalCurrency: "EUR",
+		ExchangeRate:     "404,75",
+		Balance:          "1 000 000",
+		CardNumber:       "1234******5678",
+		Location:         "Luxembourg",
+		Reference:        "REF-1",
+		Category:         "Vásárlás",
+		Extra:            map[string]string{"új oszlop": "valami"},
+		File:             "export.tsv",
+		Line:             2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transaction =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Field errors name the header of the column the field came from
+	if rowErr := transactions[0].FieldError("Amount", nil); rowErr.Column != 10 || rowErr.Field != "összeg" {
+		t.Errorf("FieldError(Amount) = column %d (%s), want column 10 (összeg)", rowErr.Column, rowErr.Field)
+	}
+}
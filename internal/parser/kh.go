@@ -2,8 +2,10 @@ package parser
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,46 +22,193 @@ type KHTransaction struct {
 	Amount         string
 	Currency       string
 	Description    string
+
+	// Optional columns, "" when the export doesn't have them
+	ValueDate        string
+	TransactionDate  string
+	OriginalAmount   string // Amount in the currency of a foreign card payment
+	OriginalCurrency string
+	ExchangeRate     string
+	Balance          string // Account balance after the transaction
+	CardNumber       string
+	Location         string
+	PartnerBank      string
+	Reference        string
+	Category         string // K&H's own category of the transaction
+
+	// Columns of the export not mapped to a field above, keyed by header name
+	Extra map[string]string
+
+	// Where the transaction was read from, for error messages and the rejects file
+	File   string
+	Line   int
+	Record []string
+
+	layout *khLayout
 }
 
-// ParseKHExport reads and parses K&H TSV export file
-func ParseKHExport(reader io.Reader) ([]*KHTransaction, error) {
+// RowError describes a row of the export that could not be read or converted
+type RowError struct {
+	File   string
+	Line   int
+	Column int      // 1-based, 0 if the error concerns the whole row
+	Field  string   // Header name of the column, e.g. "összeg"
+	Record []string // Raw fields, nil if the row could not be split into fields
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	location := fmt.Sprintf("%s:%d", e.File, e.Line)
+	if e.Column > 0 {
+		return fmt.Sprintf("%s: column %d (%s): %v", location, e.Column, e.Field, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", location, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError returns a RowError pointing at the column of a field, e.g. "Amount"
+func (t *KHTransaction) FieldError(field string, err error) *RowError {
+	rowErr := &RowError{File: t.File, Line: t.Line, Field: field, Record: t.Record, Err: err}
+	if t.layout != nil {
+		if index, ok := t.layout.columns[field]; ok {
+			rowErr.Column = index + 1
+			rowErr.Field = strings.TrimSpace(t.layout.header[index])
+		}
+	}
+	return rowErr
+}
+
+// KHReader reads a K&H TSV export one transaction at a time, so large exports
+// are processed with constant memory
+type KHReader struct {
+	csv    *csv.Reader
+	name   string
+	layout *khLayout
+	rows   int
+}
+
+// NewKHReader creates a reader for a K&H TSV export; name is used in error messages
+func NewKHReader(reader io.Reader, name string) *KHReader {
 	csvReader := csv.NewReader(reader)
 	csvReader.Comma = '\t'
 	csvReader.LazyQuotes = true
 	csvReader.FieldsPerRecord = -1 // Allow variable number of fields
+	csvReader.ReuseRecord = true
+
+	return &KHReader{csv: csvReader, name: name}
+}
+
+// Header returns the header row, available after the first call to Next
+func (r *KHReader) Header() []string {
+	if r.layout == nil {
+		return nil
+	}
+	return r.layout.header
+}
+
+// Next returns the next transaction, or io.EOF after the last one
+// A row that can't be read returns a *RowError; reading can continue with the next row
+func (r *KHReader) Next() (*KHTransaction, error) {
+	if r.layout == nil {
+		header, err := r.csv.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("file must contain at least header and one transaction")
+			}
+			return nil, fmt.Errorf("error reading TSV: %w", err)
+		}
+		// Columns are located by header name, so reordered or added columns don't shift the fields
+		layout, err := newKHLayout(append([]string(nil), header...))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.name, err)
+		}
+		r.layout = layout
+	}
+
+	record, err := r.csv.Read()
+	if err == io.EOF {
+		if r.rows == 0 {
+			return nil, fmt.Errorf("file must contain at least header and one transaction")
+		}
+		return nil, io.EOF
+	}
+	r.rows++
 
-	records, err := csvReader.ReadAll()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		// ParseError.Column is a character position, not a field
+		return nil, &RowError{File: r.name, Line: parseErr.Line, Err: fmt.Errorf("character %d: %w", parseErr.Column, parseErr.Err)}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error reading TSV: %w", err)
 	}
 
-	if len(records) < 2 {
-		return nil, fmt.Errorf("file must contain at least header and one transaction")
+	line, _ := r.csv.FieldPos(0)
+	record = append([]string(nil), record...)
+
+	layout := r.layout
+	if len(record) < layout.width {
+		return nil, &RowError{File: r.name, Line: line, Record: record,
+			Err: fmt.Errorf("expected at least %d columns, got %d", layout.width, len(record))}
 	}
 
+	return &KHTransaction{
+		Date:           layout.field(record, "Date"),
+		TransactionID:  layout.field(record, "TransactionID"),
+		Type:           layout.field(record, "Type"),
+		AccountNumber:  layout.field(record, "AccountNumber"),
+		AccountName:    layout.field(record, "AccountName"),
+		PartnerAccount: layout.field(record, "PartnerAccount"),
+		PartnerName:    layout.field(record, "PartnerName"),
+		Amount:         layout.field(record, "Amount"),
+		Currency:       layout.field(record, "Currency"),
+		Description:    layout.field(record, "Description"),
+
+		ValueDate:        layout.field(record, "ValueDate"),
+		TransactionDate:  layout.field(record, "TransactionDate"),
+		OriginalAmount:   layout.field(record, "OriginalAmount"),
+		OriginalCurrency: layout.field(record, "OriginalCurrency"),
+		ExchangeRate:     layout.field(record, "ExchangeRate"),
+		Balance:          layout.field(record, "Balance"),
+		CardNumber:       layout.field(record, "CardNumber"),
+		Location:         layout.field(record, "Location"),
+		PartnerBank:      layout.field(record, "PartnerBank"),
+		Reference:        layout.field(record, "Reference"),
+		Category:         layout.field(record, "Category"),
+
+		Extra:  layout.extraFields(record),
+		File:   r.name,
+		Line:   line,
+		Record: record,
+		layout: layout,
+	}, nil
+}
+
+// ParseKHExport reads and parses K&H TSV export file
+// Rows that can't be read are skipped and returned as row errors
+func ParseKHExport(reader io.Reader, name string) ([]*KHTransaction, []*RowError, error) {
+	khReader := NewKHReader(reader, name)
+
 	var transactions []*KHTransaction
-	for i := 1; i < len(records); i++ {
-		record := records[i]
-		if len(record) < 9 {
-			continue // Skip malformed rows
+	var rowErrors []*RowError
+	for {
+		transaction, err := khReader.Next()
+		if err == io.EOF {
+			return transactions, rowErrors, nil
 		}
-
-		transactions = append(transactions, &KHTransaction{
-			Date:           strings.TrimSpace(record[0]),
-			TransactionID:  strings.TrimSpace(record[1]),
-			Type:           strings.TrimSpace(record[2]),
-			AccountNumber:  strings.TrimSpace(record[3]),
-			AccountName:    strings.TrimSpace(record[4]),
-			PartnerAccount: strings.TrimSpace(record[5]),
-			PartnerName:    strings.TrimSpace(record[6]),
-			Amount:         strings.TrimSpace(record[7]),
-			Currency:       strings.TrimSpace(record[8]),
-			Description:    getField(record, 9),
-		})
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			rowErrors = append(rowErrors, rowErr)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		transactions = append(transactions, transaction)
 	}
-
-	return transactions, nil
 }
 
 // ParseDate parses K&H date format (YYYY.MM.DD) with optional time
@@ -80,6 +229,19 @@ func ParseDate(dateStr string) (time.Time, error) {
 	return t, nil
 }
 
+// ParseAmount parses K&H amounts with spaces as thousands separators and decimal comma
+func ParseAmount(amountStr string) (float64, error) {
+	amountStr = strings.ReplaceAll(amountStr, " ", "")
+	amountStr = strings.ReplaceAll(amountStr, ",", ".")
+	
+	amount, err := strconv.ParseFloat(amountStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %s", amountStr)
+	}
+	
+	return amount, nil
+}
+
 func getField(record []string, index int) string {
 	if index < len(record) {
 		return strings.TrimSpace(record[index])
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const khHeader = "könyvelés dátuma\ttranzakció azonosító\ttípus\tkönyvelési számla\tkönyvelési számla elnevezése\t" +
+	"partner számla\tpartner elnevezése\tösszeg\tösszeg devizaneme\tközlemény\n"
+
+func TestKHReaderRowErrors(t *testing.T) {
+	export := khHeader +
+		"2024.03.01\t1\tKártyás vásárlás\t1040\tFolyószámla\t\tTESCO\t-1 200\tHUF\t\n" +
+		"2024.03.02\t2\tKártyás vásárlás\n" +
+		"2024.03.03\t3\tKártyás vásárlás\t1040\tFolyószámla\t\tSPAR\tsok\tHUF\t\n"
+
+	transactions, rowErrors, err := ParseKHExport(strings.NewReader(export), "export.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 2 || transactions[1].Line != 4 || transactions[1].PartnerName != "SPAR" {
+		t.Fatalf("transactions = %+v", transactions)
+	}
+	if len(rowErrors) != 1 {
+		t.Fatalf("row errors = %v, want the short row", rowErrors)
+	}
+	want := "export.tsv:3: expected at least 9 columns, got 3"
+	if rowErrors[0].Error() != want {
+		t.Errorf("row error = %q, want %q", rowErrors[0], want)
+	}
+	if !reflect.DeepEqual(rowErrors[0].Record, []string{"2024.03.02", "2", "Kártyás vásárlás"}) {
+		t.Errorf("record = %q", rowErrors[0].Record)
+	}
+
+	// Field errors point at the column the field was read from
+	_, amountErr := ParseAmount(transactions[1].Amount)
+	rowErr := transactions[1].FieldError("Amount", amountErr)
+	if want := "export.tsv:4: column 8 (összeg): invalid amount: sok"; rowErr.Error() != want {
+		t.Errorf("field error = %q, want %q", rowErr, want)
+	}
+	if !errors.Is(rowErr, amountErr) {
+		t.Error("field error should wrap the parse error")
+	}
+}
+
+func TestKHReaderEmpty(t *testing.T) {
+	for _, export := range []string{"", khHeader} {
+		if _, err := NewKHReader(strings.NewReader(export), "export.tsv").Next(); err == nil || err == io.EOF {
+			t.Errorf("Next on %q = %v, want an error", export, err)
+		}
+	}
+}
+
+func TestParseDateAndAmount(t *testing.T) {
+	if date, err := ParseDate(" 2024.03.01 "); err != nil || date.Format("2006-01-02") != "2024-03-01" {
+		t.Errorf("ParseDate = %v, %v", date, err)
+	}
+	if date, err := ParseDate("2024.03.01 12:30:00"); err != nil || date.Hour() != 12 {
+		t.Errorf("ParseDate with time = %v, %v", date, err)
+	}
+	if _, err := ParseDate("03/01/2024"); err == nil {
+		t.Error("ParseDate should reject other formats")
+	}
+
+	tests := map[string]float64{"-1 200,50": -1200.5, "15000": 15000, "0,5": 0.5}
+	for input, want := range tests {
+		if got, err := ParseAmount(input); err != nil || got != want {
+			t.Errorf("ParseAmount(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseAmount("1.200,50"); err == nil {
+		t.Error("ParseAmount should reject dots as thousands separators")
+	}
+}
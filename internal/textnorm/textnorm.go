@@ -0,0 +1,23 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+var accentFolder = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ö", "o", "ő", "o", "ú", "u", "ü", "u", "ű", "u",
+	"Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ö", "O", "Ő", "O", "Ú", "U", "Ü", "U", "Ű", "U",
+)
+
+// Fold lowercases text and strips Hungarian accents so "Dániel" and "DANIEL" compare equal
+func Fold(text string) string {
+	return strings.ToLower(accentFolder.Replace(text))
+}
+
+// Tokens folds text and splits it into letter/digit runs
+func Tokens(text string) []string {
+	return strings.FieldsFunc(Fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,30 @@
+package textnorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := map[string]string{
+		"Dániel":                 "daniel",
+		"ŐRSÉG ÜZLETHÁZ":         "orseg uzlethaz",
+		"árvíztűrő tükörfúrógép": "arvizturo tukorfurogep",
+		"TESCO":                  "tesco",
+	}
+	for input, want := range tests {
+		if got := Fold(input); got != want {
+			t.Errorf("Fold(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	got := Tokens("  Kovács-Nagy Béla, 2. ker.\ufeff")
+	if want := []string{"kovacs", "nagy", "bela", "2", "ker"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens = %q, want %q", got, want)
+	}
+	if got := Tokens(" - "); len(got) != 0 {
+		t.Errorf("Tokens of punctuation = %q, want none", got)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"text/template"
 
 	"ezbook-convert/cmd"
+	"ezbook-convert/internal/config"
 )
 
 const version = "1.0.0"
@@ -19,6 +21,14 @@ Usage:
 Commands:
   convert        Convert K&H TSV to ezBookkeeping CSV
   update-config  Generate LLM prompt for updating categorization config
+  train          Train the offline classifier on converted ezBookkeeping CSVs
+  learn          Learn rules from categories corrected in ezBookkeeping
+  explain        Show which rule categorized each transaction
+  categorize     Categorize unknown partners interactively in the terminal
+  lint           Check the config for conflicting and dead rules
+  repair-config  Fix malformed LLM-generated YAML config
+  migrate-config Upgrade the config file to the current layout
+  schema         Print the JSON Schema of the config file for editors
   version        Show version information
   help           Show this help message
 
@@ -26,17 +36,88 @@ Convert flags:
   --input        Input K&H TSV file path (required)
   --output       Output ezBookkeeping CSV file path (required)
   --account-name Account name for transactions (required)
-  --config       YAML config file path (optional)
+  --config       YAML config file path (default: categories.yaml or the user config, if present)
+  --strict       Fail on the first row that can't be read or converted
+  --rejects      Write rows that were skipped to this TSV file
 
 Update-config flags:
+  --input        Input K&H TSV file path (required unless --apply is given)
+  --config       YAML config file path (default: categories.yaml, then the user config)
+  --apply        Merge LLM response file(s) into the config instead of generating a prompt, repeatable
+  --allow-removals  With --apply, delete rules missing from the response
+  --mapping      Local placeholder mapping file (default: placeholders.yaml)
+  --owner        Account owner name to anonymize, repeatable (default: owners from config)
+  --template     Prompt template: built-in en or hu, or a template file (default: en)
+  --prompt-out   Write only the prompt to this file instead of stdout
+  --format       Output format: prompt or json (default: prompt)
+  --max-tokens   Split the prompt into parts of about this many tokens, 0 disables (default: 6000)
+  --llm-endpoint Send the prompt to an OpenAI-compatible API and merge the answer (API key from LLM_API_KEY)
+  --model        Model name for --llm-endpoint
+
+Train flags:
+  --input        ezBookkeeping CSV file path, repeatable (required)
+  --model        Output model file path (default: model.json)
+
+Learn flags:
+  --export       ezBookkeeping export CSV file path (required)
+  --original     Converted CSV file path, repeatable (required)
+  --config       YAML config file path (default: categories.yaml, then the user config)
+  --write        Save the proposed rules to the config
+
+Explain flags:
+  --input        Input K&H TSV file path (required)
+  --config       YAML config file path (default: categories.yaml or the user config, if present)
+  --id           Only explain the transaction with this ID
+
+Categorize flags:
   --input        Input K&H TSV file path (required)
+  --config       YAML config file path (default: categories.yaml, then the user config)
+
+Lint flags:
+  --config       YAML config file path (default: categories.yaml, then the user config)
+  --input        Input K&H TSV file path, reports rules that never matched (optional)
+
+Repair-config flags:
   --config       YAML config file path (default: categories.yaml)
+  --output       Output file path (default: overwrite --config)
+
+Migrate-config flags:
+  --config       YAML config file path (default: categories.yaml, then the user config)
+  --write        Save the migrated config (default: only show the changes)
+
+Schema flags:
+  --output       Output file path (default: stdout)
 
 Examples:
   ezbook-convert convert --input kh.csv --output ezbook.csv --account-name "K&H" --config categories.yaml
   ezbook-convert update-config --input kh.csv --config categories.yaml
+  ezbook-convert update-config --input kh.csv --owner "Vigh Dániel" --owner "Vigh Anna" --prompt-out prompt.txt
+  ezbook-convert update-config --input kh.csv --template hu --format json > merchants.json
+  ezbook-convert update-config --apply response.txt --config categories.yaml
+  ezbook-convert update-config --apply response-1.txt --apply response-2.txt --config categories.yaml
+  ezbook-convert update-config --input kh.csv --llm-endpoint http://localhost:11434/v1 --model qwen2.5:14b
+  ezbook-convert train --input ezbook_2025_10.csv --input ezbook_2025_11.csv --model model.json
+  ezbook-convert learn --export ezbookkeeping_export.csv --original ezbook_2025_11.csv --write
+  ezbook-convert explain --input kh.csv --config categories.yaml --id 123456789
+  ezbook-convert categorize --input kh.csv --config categories.yaml
+  ezbook-convert lint --config categories.yaml --input kh.csv
+  ezbook-convert repair-config --config llm_response.yaml --output categories.yaml
+  ezbook-convert migrate-config --config categories.yaml --write
+  ezbook-convert schema --output categories.schema.json
 `
 
+// stringList is a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
@@ -50,6 +131,22 @@ func main() {
 		runConvert()
 	case "update-config":
 		runUpdateConfig()
+	case "train":
+		runTrain()
+	case "learn":
+		runLearn()
+	case "explain":
+		runExplain()
+	case "categorize":
+		runCategorize()
+	case "lint":
+		runLint()
+	case "repair-config":
+		runRepairConfig()
+	case "migrate-config":
+		runMigrateConfig()
+	case "schema":
+		runSchema()
 	case "version":
 		fmt.Printf("ezbook-convert version %s\n", version)
 	case "help", "--help", "-h":
@@ -66,9 +163,12 @@ func runConvert() {
 	inputPath := fs.String("input", "", "Input K&H TSV file path (required)")
 	outputPath := fs.String("output", "", "Output ezBookkeeping CSV file path (required)")
 	accountName := fs.String("account-name", "", "Account name for transactions (required)")
-	configPath := fs.String("config", "", "YAML config file path (optional)")
+	configPath := fs.String("config", "", "YAML config file path (default: categories.yaml or the user config, if present)")
+	strict := fs.Bool("strict", false, "Fail on the first row that can't be read or converted")
+	rejectsPath := fs.String("rejects", "", "Write rows that were skipped to this TSV file")
 
 	fs.Parse(os.Args[2:])
+	*configPath = resolveConfig(*configPath, "")
 
 	if *inputPath == "" || *outputPath == "" || *accountName == "" {
 		fmt.Fprintf(os.Stderr, "Error: --input, --output, and --account-name are required\n\n")
@@ -76,7 +176,15 @@ func runConvert() {
 		os.Exit(1)
 	}
 
-	if err := cmd.ConvertCmd(*inputPath, *outputPath, *accountName, *configPath); err != nil {
+	opts := cmd.ConvertOptions{
+		InputPath:   *inputPath,
+		OutputPath:  *outputPath,
+		AccountName: *accountName,
+		ConfigPath:  *configPath,
+		Strict:      *strict,
+		RejectsPath: *rejectsPath,
+	}
+	if err := cmd.ConvertCmd(opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -85,9 +193,30 @@ func runConvert() {
 func runUpdateConfig() {
 	fs := flag.NewFlagSet("update-config", flag.ExitOnError)
 	inputPath := fs.String("input", "", "Input K&H TSV file path (required)")
-	configPath := fs.String("config", "categories.yaml", "YAML config file path")
+	configPath := fs.String("config", "", "YAML config file path (default: categories.yaml, then the user config)")
+	var applyPaths stringList
+	fs.Var(&applyPaths, "apply", "Merge LLM response file(s) into the config instead of generating a prompt, repeatable")
+	allowRemovals := fs.Bool("allow-removals", false, "With --apply, delete rules missing from the response")
+	mappingPath := fs.String("mapping", "placeholders.yaml", "Local placeholder mapping file")
+	var owners stringList
+	fs.Var(&owners, "owner", "Account owner name to anonymize, repeatable for joint accounts")
+	templateName := fs.String("template", "en", "Prompt template: built-in en or hu, or a template file path")
+	promptOut := fs.String("prompt-out", "", "Write only the prompt to this file instead of stdout")
+	format := fs.String("format", "prompt", "Output format: prompt or json (list of unknown merchants)")
+	maxTokens := fs.Int("max-tokens", 6000, "Split the prompt into parts of about this many tokens, 0 disables splitting")
+	llmEndpoint := fs.String("llm-endpoint", "", "OpenAI-compatible API URL to send the prompt to, e.g. http://localhost:11434/v1")
+	llmModel := fs.String("model", "", "Model name for --llm-endpoint")
 
 	fs.Parse(os.Args[2:])
+	*configPath = resolveConfig(*configPath, config.DefaultConfigName)
+
+	if len(applyPaths) > 0 {
+		if err := cmd.ApplyResponseCmd(applyPaths, *configPath, *mappingPath, *allowRemovals); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	if *inputPath == "" {
 		fmt.Fprintf(os.Stderr, "Error: --input is required\n\n")
@@ -95,12 +224,178 @@ func runUpdateConfig() {
 		os.Exit(1)
 	}
 
-	if err := cmd.UpdateConfigCmd(*inputPath, *configPath); err != nil {
+	opts := cmd.UpdateConfigOptions{
+		InputPath:   *inputPath,
+		ConfigPath:  *configPath,
+		MappingPath: *mappingPath,
+		Owners:      owners,
+		Template:    *templateName,
+		PromptOut:   *promptOut,
+		Format:      *format,
+		MaxTokens:   *maxTokens,
+		LLMEndpoint: *llmEndpoint,
+		LLMModel:    *llmModel,
+		LLMAPIKey:   os.Getenv("LLM_API_KEY"),
+	}
+	if err := cmd.UpdateConfigCmd(opts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+func runTrain() {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	var inputPaths stringList
+	fs.Var(&inputPaths, "input", "ezBookkeeping CSV file path, repeatable (required)")
+	modelPath := fs.String("model", "model.json", "Output model file path")
+
+	fs.Parse(os.Args[2:])
+
+	if len(inputPaths) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: --input is required\n\n")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if err := cmd.TrainCmd(inputPaths, *modelPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runLearn() {
+	fs := flag.NewFlagSet("learn", flag.ExitOnError)
+	exportPath := fs.String("export", "", "ezBookkeeping export CSV file path (required)")
+	var originalPaths stringList
+	fs.Var(&originalPaths, "original", "Converted CSV file path, repeatable (required)")
+	configPath := fs.String("config", "", "YAML config file path (default: categories.yaml, then the user config)")
+	write := fs.Bool("write", false, "Save the proposed rules to the config")
+
+	fs.Parse(os.Args[2:])
+	*configPath = resolveConfig(*configPath, config.DefaultConfigName)
+
+	if *exportPath == "" || len(originalPaths) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: --export and --original are required\n\n")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if err := cmd.LearnCmd(*exportPath, originalPaths, *configPath, *write); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runExplain() {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	inputPath := fs.String("input", "", "Input K&H TSV file path (required)")
+	configPath := fs.String("config", "", "YAML config file path (default: categories.yaml or the user config, if present)")
+	transactionID := fs.String("id", "", "Only explain the transaction with this ID")
+
+	fs.Parse(os.Args[2:])
+	*configPath = resolveConfig(*configPath, "")
+
+	if *inputPath == "" {
+		fmt.Fprintf(os.Stderr, "Error: --input is required\n\n")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if err := cmd.ExplainCmd(*inputPath, *configPath, *transactionID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runCategorize() {
+	fs := flag.NewFlagSet("categorize", flag.ExitOnError)
+	inputPath := fs.String("input", "", "Input K&H TSV file path (required)")
+	configPath := fs.String("config", "", "YAML config file path (default: categories.yaml, then the user config)")
+
+	fs.Parse(os.Args[2:])
+	*configPath = resolveConfig(*configPath, config.DefaultConfigName)
+
+	if *inputPath == "" {
+		fmt.Fprintf(os.Stderr, "Error: --input is required\n\n")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	if err := cmd.CategorizeCmd(*inputPath, *configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runLint() {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	configPath := fs.String("config", "", "YAML config file path (default: categories.yaml, then the user config)")
+	inputPath := fs.String("input", "", "Input K&H TSV file path, reports rules that never matched (optional)")
+
+	fs.Parse(os.Args[2:])
+	*configPath = resolveConfig(*configPath, config.DefaultConfigName)
+
+	if err := cmd.LintCmd(*configPath, *inputPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runRepairConfig() {
+	fs := flag.NewFlagSet("repair-config", flag.ExitOnError)
+	configPath := fs.String("config", "categories.yaml", "YAML config file path")
+	outputPath := fs.String("output", "", "Output file path (default: overwrite --config)")
+
+	fs.Parse(os.Args[2:])
+
+	if *outputPath == "" {
+		*outputPath = *configPath
+	}
+
+	if err := cmd.RepairConfigCmd(*configPath, *outputPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runMigrateConfig() {
+	fs := flag.NewFlagSet("migrate-config", flag.ExitOnError)
+	configPath := fs.String("config", "", "YAML config file path (default: categories.yaml, then the user config)")
+	write := fs.Bool("write", false, "Save the migrated config")
+
+	fs.Parse(os.Args[2:])
+	*configPath = resolveConfig(*configPath, config.DefaultConfigName)
+
+	if err := cmd.MigrateConfigCmd(*configPath, *write); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runSchema() {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	outputPath := fs.String("output", "", "Output file path (default: stdout)")
+
+	fs.Parse(os.Args[2:])
+
+	if err := cmd.SchemaCmd(*outputPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// resolveConfig returns the config path to use when --config is not given:
+// categories.yaml in the working directory, then the user-level config, else fallback
+func resolveConfig(path, fallback string) string {
+	if path != "" {
+		return path
+	}
+	if found := config.FindConfig(); found != "" {
+		return found
+	}
+	return fallback
+}
+
 func printUsage() {
 	tmpl := template.Must(template.New("help").Parse(helpTemplate))
 	tmpl.Execute(os.Stdout, nil)